@@ -0,0 +1,111 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// AliasMap maps canonical check IDs to the ID of the equivalent check in each benchmark,
+// for example "apiserver-profiling" to {"cis-1.8": "1.2.16", "cis-1.9": "1.2.15"}.
+type AliasMap map[string]map[string]string
+
+// QualifiedID returns the ID of a check qualified with its benchmark, as used in aliases.
+func QualifiedID(benchmark, id string) string {
+	return benchmark + ":" + id
+}
+
+// isQualified reports whether alias refers to a check of a given benchmark, rather than
+// being a canonical ID.
+func isQualified(alias string) bool {
+	return strings.Contains(alias, ":")
+}
+
+// Matches reports whether id is the ID of the check or one of its aliases.
+func (c *Check) Matches(id string) bool {
+	return c.ID == id || slices.Contains(c.Aliases, id)
+}
+
+// MatchesAny reports whether the ID of the check or one of its aliases is in ids.
+func (c *Check) MatchesAny(ids map[string]bool) bool {
+	if ids[c.ID] {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if ids[alias] {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAliases adds the aliases not yet known to the check, and updates its canonical ID.
+func (c *Check) AddAliases(aliases ...string) {
+	for _, alias := range aliases {
+		if alias != "" && alias != c.ID && !slices.Contains(c.Aliases, alias) {
+			c.Aliases = append(c.Aliases, alias)
+		}
+	}
+	c.setCanonicalID()
+}
+
+// setCanonicalID sets the canonical ID of the check to its first alias that isn't qualified
+// with a benchmark.
+func (c *Check) setCanonicalID() {
+	c.CanonicalID = ""
+	for _, alias := range c.Aliases {
+		if !isQualified(alias) {
+			c.CanonicalID = alias
+			return
+		}
+	}
+}
+
+// ApplyAliases adds to the checks of benchmark the aliases recorded for them in aliases:
+// their canonical ID and their qualified IDs in the other benchmarks.
+func (controls *Controls) ApplyAliases(benchmark string, aliases AliasMap) {
+	byID := make(map[string][]string)
+	canonicalIDs := make([]string, 0, len(aliases))
+	for canonical := range aliases {
+		canonicalIDs = append(canonicalIDs, canonical)
+	}
+	sort.Strings(canonicalIDs)
+
+	for _, canonical := range canonicalIDs {
+		ids := aliases[canonical]
+		id, ok := ids[benchmark]
+		if !ok {
+			continue
+		}
+		byID[id] = append(byID[id], canonical)
+
+		others := make([]string, 0, len(ids))
+		for b, otherID := range ids {
+			if b != benchmark {
+				others = append(others, QualifiedID(b, otherID))
+			}
+		}
+		sort.Strings(others)
+		byID[id] = append(byID[id], others...)
+	}
+
+	for _, group := range controls.Groups {
+		for _, c := range group.Checks {
+			c.AddAliases(byID[c.ID]...)
+		}
+	}
+}
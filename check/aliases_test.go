@@ -0,0 +1,107 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAliases(t *testing.T) {
+	in := []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: 1.2.15
+    aliases: ["cis-1.8:1.2.16", "apiserver-profiling"]
+  - id: 1.2.16
+  - id: 1.2.17
+`)
+	controls, err := NewControls(MASTER, in, "")
+	assert.NoError(t, err)
+
+	checks := controls.Groups[0].Checks
+	assert.Equal(t, "apiserver-profiling", checks[0].CanonicalID)
+	assert.Equal(t, "", checks[1].CanonicalID)
+
+	controls.ApplyAliases("cis-1.9", AliasMap{
+		"apiserver-profiling":      {"cis-1.8": "1.2.16", "cis-1.9": "1.2.15"},
+		"apiserver-audit-log-path": {"cis-1.9": "1.2.16", "cis-1.8": "1.2.17", "cis-1.7": "1.2.18"},
+		"apiserver-etcd-cafile":    {"cis-1.8": "1.2.27"},
+	})
+
+	testCases := []struct {
+		id        string
+		canonical string
+		aliases   []string
+	}{
+		{id: "1.2.15", canonical: "apiserver-profiling", aliases: []string{"cis-1.8:1.2.16", "apiserver-profiling"}},
+		{id: "1.2.16", canonical: "apiserver-audit-log-path", aliases: []string{"apiserver-audit-log-path", "cis-1.7:1.2.18", "cis-1.8:1.2.17"}},
+		{id: "1.2.17", canonical: "", aliases: nil},
+	}
+	for i, c := range testCases {
+		t.Run(c.id, func(t *testing.T) {
+			assert.Equal(t, c.id, checks[i].ID)
+			assert.Equal(t, c.canonical, checks[i].CanonicalID)
+			assert.Equal(t, c.aliases, checks[i].Aliases)
+		})
+	}
+
+	assert.True(t, checks[1].Matches("1.2.16"))
+	assert.True(t, checks[1].Matches("cis-1.8:1.2.17"))
+	assert.False(t, checks[1].Matches("1.2.17"))
+	assert.True(t, checks[1].MatchesAny(map[string]bool{"C1": true, "apiserver-audit-log-path": true}))
+	assert.False(t, checks[2].MatchesAny(map[string]bool{"apiserver-audit-log-path": true}))
+
+	out, err := json.Marshal(checks[1])
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"test_number":"1.2.16","canonical_id":"apiserver-audit-log-path","aliases":["apiserver-audit-log-path","cis-1.7:1.2.18","cis-1.8:1.2.17"]`)
+
+	out, err = json.Marshal(checks[2])
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "canonical_id")
+	assert.NotContains(t, string(out), "aliases")
+}
+
+func TestControls_RunChecks_SkippedByAlias(t *testing.T) {
+	in := []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: 1.2.15
+  - id: 1.2.16
+    aliases: ["apiserver-audit-log-path"]
+  - id: 1.2.17
+`)
+	controls, err := NewControls(MASTER, in, "")
+	assert.NoError(t, err)
+	controls.ApplyAliases("cis-1.9", AliasMap{"apiserver-profiling": {"cis-1.9": "1.2.15"}})
+
+	var allChecks Predicate = func(group *Group, c *Check) bool {
+		return true
+	}
+	controls.RunChecks(&defaultRunner{}, allChecks, map[string]bool{"apiserver-profiling": true, "apiserver-audit-log-path": true})
+
+	checks := controls.Groups[0].Checks
+	assert.Equal(t, SKIP, checks[0].Type)
+	assert.Equal(t, SKIP, checks[1].Type)
+	assert.NotEqual(t, SKIP, checks[2].Type)
+}
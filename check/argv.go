@@ -0,0 +1,113 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"strings"
+)
+
+// parseFlags returns the command-line flags found in s, mapped to their values.
+// s holds one command line per row, such as the output of ps, or the NUL-separated
+// argv read from /proc/<pid>/cmdline. As with the flag parsing of the Kubernetes
+// components, a flag given several times takes its last value, and --flag=value,
+// --flag value and --flag: value, as in a YAML list of arguments, are understood.
+// A flag followed by another flag or by nothing has no value, and is "true".
+func parseFlags(s string) map[string]string {
+	flags := make(map[string]string)
+	for _, line := range strings.Split(s, "\n") {
+		var args []string
+		if strings.Contains(line, "\x00") {
+			args = strings.Split(strings.TrimRight(line, "\x00"), "\x00")
+		} else {
+			args = splitArgs(line)
+		}
+		parseArgv(expandArgs(args), flags)
+	}
+	return flags
+}
+
+func parseArgv(args []string, flags map[string]string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || strings.Trim(arg, "-") == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue {
+			// --flag: value, as in a YAML list of arguments
+			name = strings.TrimSuffix(name, ":")
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				value = args[i+1]
+				i++
+			} else {
+				value = "true"
+			}
+		}
+		flags[name] = value
+	}
+}
+
+// expandArgs adds the arguments found in the values of other arguments,
+// such as KUBELET_ARGS="--config=/var/lib/kubelet/config.yaml" in a systemd unit file.
+func expandArgs(args []string) []string {
+	var expanded []string
+	for _, arg := range args {
+		expanded = append(expanded, arg)
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if _, value, ok := strings.Cut(arg, "="); ok && strings.Contains(value, "-") {
+			expanded = append(expanded, expandArgs(splitArgs(value))...)
+		}
+	}
+	return expanded
+}
+
+// splitArgs splits s into arguments on whitespace, as a shell would.
+// Single and double quotes group words into one argument and are removed.
+func splitArgs(s string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
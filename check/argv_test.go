@@ -0,0 +1,168 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFlags(t *testing.T) {
+	cases := []struct {
+		name     string
+		s        string
+		expected map[string]string
+	}{
+		{
+			name:     "ps output",
+			s:        "root  1234  1  2 10:00 ?  00:01:02 /usr/local/bin/kube-apiserver --profiling=false --anonymous-auth",
+			expected: map[string]string{"--profiling": "false", "--anonymous-auth": "true"},
+		},
+		{
+			name:     "flag is not matched by a longer flag",
+			s:        "kube-apiserver --authorization-mode-webhook-cache=5m",
+			expected: map[string]string{"--authorization-mode-webhook-cache": "5m"},
+		},
+		{
+			name:     "last value wins",
+			s:        "kube-apiserver --authorization-mode=AlwaysAllow --authorization-mode=Node,RBAC",
+			expected: map[string]string{"--authorization-mode": "Node,RBAC"},
+		},
+		{
+			name:     "value after a space",
+			s:        "kubelet --config /var/lib/kubelet/config.yaml --rotate-certificates --v 2",
+			expected: map[string]string{"--config": "/var/lib/kubelet/config.yaml", "--rotate-certificates": "true", "--v": "2"},
+		},
+		{
+			name:     "quoted values",
+			s:        `kube-apiserver --tls-cipher-suites="TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384" --admission-control-config-file='/etc/admission config.yaml'`,
+			expected: map[string]string{"--tls-cipher-suites": "TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384", "--admission-control-config-file": "/etc/admission config.yaml"},
+		},
+		{
+			name:     "value containing =",
+			s:        "kubelet --feature-gates=RotateKubeletServerCertificate=true",
+			expected: map[string]string{"--feature-gates": "RotateKubeletServerCertificate=true"},
+		},
+		{
+			name:     "NUL-separated cmdline",
+			s:        "/usr/bin/kubelet\x00--anonymous-auth=false\x00--config\x00/etc/kubelet.yaml\x00--anonymous-auth=true\x00",
+			expected: map[string]string{"--anonymous-auth": "true", "--config": "/etc/kubelet.yaml"},
+		},
+		{
+			name:     "NUL-separated arguments containing spaces and quotes",
+			s:        "kube-apiserver\x00--admission-control-config-file=/etc/kubernetes/admission control.yaml\x00--tls-cipher-suites\x00TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384\x00--motd=it's \"up\"\x00",
+			expected: map[string]string{"--admission-control-config-file": "/etc/kubernetes/admission control.yaml", "--tls-cipher-suites": "TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384", "--motd": "it's \"up\""},
+		},
+		{
+			name:     "systemd unit file",
+			s:        "Environment=\"KUBELET_CONFIG_ARGS=--config=/var/lib/kubelet/config.yaml --read-only-port=0\"\nExecStart=/usr/bin/kubelet $KUBELET_CONFIG_ARGS",
+			expected: map[string]string{"--config": "/var/lib/kubelet/config.yaml", "--read-only-port": "0"},
+		},
+		{
+			name:     "static pod manifest",
+			s:        "    command:\n    - kube-scheduler\n    - --profiling=false\n    - --bind-address: 127.0.0.1",
+			expected: map[string]string{"--profiling": "false", "--bind-address": "127.0.0.1"},
+		},
+		{
+			name:     "a value is not read from the next row",
+			s:        "kubelet --anonymous-auth\nroot 42 kubelet",
+			expected: map[string]string{"--anonymous-auth": "true"},
+		},
+		{
+			name:     "empty",
+			s:        "",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, parseFlags(c.s))
+		})
+	}
+}
+
+func TestFlagTestItemFindValuePrefixCollision(t *testing.T) {
+	ft := flagTestItem{Flag: "--authorization-mode"}
+	match, value, err := ft.findValue("kube-apiserver --authorization-mode-webhook=true")
+	assert.NoError(t, err)
+	assert.False(t, match)
+	assert.Empty(t, value)
+
+	match, value, err = ft.findValue("kube-apiserver --authorization-mode-webhook=true --authorization-mode=RBAC")
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.Equal(t, "RBAC", value)
+}
+
+func TestFlagTestItemFindValueWithValue(t *testing.T) {
+	cases := []struct {
+		name           string
+		item           testItem
+		s              string
+		expectedResult bool
+	}{
+		{
+			name:           "set: false with the value given",
+			item:           testItem{Flag: "--rotate-certificates=false", Set: false},
+			s:              "root 42 1 0 10:00 ? 00:00:01 kubelet --rotate-certificates=false",
+			expectedResult: false,
+		},
+		{
+			name:           "set: false with another value",
+			item:           testItem{Flag: "--rotate-certificates=false", Set: false},
+			s:              "root 42 1 0 10:00 ? 00:00:01 kubelet --rotate-certificates=true",
+			expectedResult: true,
+		},
+		{
+			name:           "set: false without the flag",
+			item:           testItem{Flag: "--rotate-certificates=false", Set: false},
+			s:              "root 42 1 0 10:00 ? 00:00:01 kubelet --v=2",
+			expectedResult: true,
+		},
+		{
+			name:           "set: true with the value given",
+			item:           testItem{Flag: "--authorization-mode=Node,RBAC", Set: true},
+			s:              "kube-apiserver --authorization-mode=Node,RBAC --anonymous-auth=false",
+			expectedResult: true,
+		},
+		{
+			name:           "set: true with another value",
+			item:           testItem{Flag: "--authorization-mode=Node,RBAC", Set: true},
+			s:              "kube-apiserver --authorization-mode=AlwaysAllow",
+			expectedResult: false,
+		},
+		{
+			name:           "nothave with the value after a space",
+			item:           testItem{Flag: "--authorization-mode", Set: true, Compare: compare{Op: "nothave", Value: "AlwaysAllow"}},
+			s:              "kube-apiserver --authorization-mode AlwaysAllow --anonymous-auth=false",
+			expectedResult: false,
+		},
+		{
+			name:           "boolean flag followed by another flag",
+			item:           testItem{Flag: "--anonymous-auth", Set: true, Compare: compare{Op: "eq", Value: "true"}},
+			s:              "kubelet --anonymous-auth --v=2",
+			expectedResult: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.item.auditUsed = AuditCommand
+			assert.Equal(t, c.expectedResult, c.item.execute(c.s).testResult)
+		})
+	}
+}
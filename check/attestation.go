@@ -0,0 +1,71 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// AttestedPass is the outcome of a manual check a reviewer found compliant.
+	AttestedPass = "pass"
+	// AttestedFail is the outcome of a manual check a reviewer found not compliant.
+	AttestedFail = "fail"
+)
+
+// Attestation records the outcome of a manual check reviewed by a person.
+type Attestation struct {
+	ID       string `yaml:"id" json:"-"`
+	Outcome  string `yaml:"outcome" json:"outcome"`
+	Evidence string `yaml:"evidence" json:"evidence"`
+	Reviewer string `yaml:"reviewer" json:"reviewer"`
+	Date     string `yaml:"date" json:"date"`
+}
+
+// State returns the state of a check with the attestation.
+func (a *Attestation) State() State {
+	if a.Outcome == AttestedPass {
+		return PASS
+	}
+	return FAIL
+}
+
+func (a *Attestation) reason() string {
+	return fmt.Sprintf("Attested by %s on %s: %s", a.Reviewer, a.Date, a.Evidence)
+}
+
+// ApplyAttestations records on the manual checks of benchmark the attestations made for them, by ID,
+// canonical ID, alias or ID qualified with benchmark. Attestations of checks that aren't manual are
+// ignored, since their tests decide their state.
+func (controls *Controls) ApplyAttestations(benchmark string, attestations []Attestation) {
+	for _, group := range controls.Groups {
+		for _, c := range group.Checks {
+			for i := range attestations {
+				a := &attestations[i]
+				if !c.Matches(a.ID) && a.ID != QualifiedID(benchmark, c.ID) {
+					continue
+				}
+				if c.Type != MANUAL {
+					glog.Warningf("Ignoring the attestation of check %s, which isn't manual", c.ID)
+					break
+				}
+				c.Attestation = a
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControls_ApplyAttestations(t *testing.T) {
+	in := []byte(`
+---
+type: "policies"
+groups:
+- id: "5.1"
+  checks:
+  - id: 5.1.7
+    type: manual
+  - id: 5.1.8
+    type: manual
+  - id: 5.1.9
+    type: manual
+    aliases: ["rbac-persistent-volumes"]
+  - id: 5.1.10
+    type: manual
+    aliases: ["cis-1.11:5.1.11"]
+  - id: 5.1.11
+    type: manual
+  - id: 5.1.12
+`)
+	controls, err := NewControls(POLICIES, in, "")
+	assert.NoError(t, err)
+
+	controls.ApplyAttestations("cis-1.12", []Attestation{
+		{ID: "5.1.7", Outcome: AttestedPass, Evidence: "no binding to system:masters", Reviewer: "alice", Date: "2026-10-01"},
+		{ID: "cis-1.12:5.1.8", Outcome: AttestedFail, Evidence: "impersonate granted to CI", Reviewer: "bob", Date: "2026-10-02"},
+		{ID: "rbac-persistent-volumes", Outcome: AttestedPass, Evidence: "reviewed", Reviewer: "alice", Date: "2026-10-03"},
+		{ID: "cis-1.11:5.1.11", Outcome: AttestedPass, Evidence: "reviewed", Reviewer: "alice", Date: "2026-10-04"},
+		{ID: "5.1.12", Outcome: AttestedPass, Evidence: "reviewed", Reviewer: "alice", Date: "2026-10-05"},
+	})
+
+	var allChecks Predicate = func(group *Group, c *Check) bool {
+		return true
+	}
+	controls.RunChecks(&defaultRunner{}, allChecks, nil)
+
+	testCases := []struct {
+		id     string
+		state  State
+		reason string
+	}{
+		{id: "5.1.7", state: PASS, reason: "Attested by alice on 2026-10-01: no binding to system:masters"},
+		{id: "5.1.8", state: FAIL, reason: "Attested by bob on 2026-10-02: impersonate granted to CI"},
+		{id: "5.1.9", state: PASS, reason: "Attested by alice on 2026-10-03: reviewed"},
+		{id: "5.1.10", state: PASS, reason: "Attested by alice on 2026-10-04: reviewed"},
+		{id: "5.1.11", state: WARN, reason: "Test marked as a manual test"},
+	}
+	checks := controls.Groups[0].Checks
+	for i, c := range testCases {
+		t.Run(c.id, func(t *testing.T) {
+			assert.Equal(t, c.id, checks[i].ID)
+			assert.Equal(t, c.state, checks[i].State)
+			assert.Equal(t, c.reason, checks[i].Reason)
+		})
+	}
+	assert.Nil(t, checks[5].Attestation, "checks that aren't manual aren't attested")
+	assert.Equal(t, Summary{Pass: 3, Fail: 1, Warn: 2}, controls.Summary)
+
+	out, err := json.Marshal(checks[1])
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"attestation":{"outcome":"fail","evidence":"impersonate granted to CI","reviewer":"bob","date":"2026-10-02"}`)
+
+	out, err = json.Marshal(checks[4])
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "attestation")
+}
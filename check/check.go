@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 )
@@ -65,26 +66,35 @@ const (
 // Check contains information about a recommendation in the
 // CIS Kubernetes document.
 type Check struct {
-	ID                string   `yaml:"id" json:"test_number"`
-	Text              string   `json:"test_desc"`
-	Audit             string   `json:"audit"`
-	AuditEnv          string   `yaml:"audit_env"`
-	AuditConfig       string   `yaml:"audit_config"`
-	Type              string   `json:"type"`
-	Tests             *tests   `json:"-"`
-	Set               bool     `json:"-"`
-	Remediation       string   `json:"remediation"`
-	TestInfo          []string `json:"test_info"`
-	State             `json:"status"`
-	ActualValue       string `json:"actual_value"`
-	Scored            bool   `json:"scored"`
-	IsMultiple        bool   `yaml:"use_multiple_values"`
-	ExpectedResult    string `json:"expected_result"`
-	Reason            string `json:"reason,omitempty"`
-	AuditOutput       string `json:"-"`
-	AuditEnvOutput    string `json:"-"`
-	AuditConfigOutput string `json:"-"`
-	DisableEnvTesting bool   `json:"-"`
+	ID          string   `yaml:"id" json:"test_number"`
+	CanonicalID string   `yaml:"-" json:"canonical_id,omitempty"`
+	Aliases     []string `yaml:"aliases" json:"aliases,omitempty"`
+	Tags        []string `yaml:"tags" json:"tags,omitempty"`
+	Text        string   `json:"test_desc"`
+	Audit       string   `json:"audit"`
+	// PID is the process whose command line the audit reads, if it's the process of a component.
+	PID                int      `yaml:"-" json:"pid,omitempty"`
+	AuditEnv           string   `yaml:"audit_env"`
+	AuditConfig        string   `yaml:"audit_config"`
+	Type               string   `json:"type"`
+	Tests              *tests   `json:"-"`
+	Set                bool     `json:"-"`
+	Remediation        string   `json:"remediation"`
+	TestInfo           []string `json:"test_info"`
+	State              `json:"status"`
+	ActualValue        string       `json:"actual_value"`
+	Scored             bool         `json:"scored"`
+	IsMultiple         bool         `yaml:"use_multiple_values"`
+	ExpectedResult     string       `json:"expected_result"`
+	Reason             string       `json:"reason,omitempty"`
+	Attestation        *Attestation `yaml:"-" json:"attestation,omitempty"`
+	AuditOutput        string       `json:"-"`
+	AuditEnvOutput     string       `json:"-"`
+	AuditConfigOutput  string       `json:"-"`
+	AuditConfigz       string       `yaml:"-" json:"-"`
+	AuditConfigzOutput string       `json:"-"`
+	DisableEnvTesting  bool         `json:"-"`
+	Trace              *Trace       `yaml:"-" json:"-"`
 }
 
 // Runner wraps the basic Run method.
@@ -108,6 +118,9 @@ func (r *defaultRunner) Run(c *Check) State {
 // the results.
 func (c *Check) run() State {
 	glog.V(3).Infof("-----   Running check %v   -----", c.ID)
+	c.Trace = newTrace(c)
+	defer func() { c.Trace.finish(c) }()
+
 	// Since this is an Scored check
 	// without tests return a 'WARN' to alert
 	// the user that this check needs attention
@@ -126,8 +139,14 @@ func (c *Check) run() State {
 		return c.State
 	}
 
-	// If check type is manual force result to WARN
+	// If check type is manual force result to WARN, unless a reviewer attested its outcome
 	if c.Type == MANUAL {
+		if c.Attestation != nil {
+			c.Reason = c.Attestation.reason()
+			c.State = c.Attestation.State()
+			glog.V(3).Info(c.Reason)
+			return c.State
+		}
 		c.Reason = "Test marked as a manual test"
 		c.State = WARN
 		glog.V(3).Info(c.Reason)
@@ -195,19 +214,23 @@ func (c *Check) run() State {
 func (c *Check) runAuditCommands() (lastCommand string, err error) {
 	// Always run auditEnvOutput if needed
 	if c.AuditEnv != "" {
-		c.AuditEnvOutput, err = runAudit(c.AuditEnv)
+		c.AuditEnvOutput, err = c.runAudit(AuditEnv, c.AuditEnv)
 		if err != nil {
 			return c.AuditEnv, err
 		}
 	}
 
 	// Run the audit command and auditConfig commands, if present
-	c.AuditOutput, err = runAudit(c.Audit)
+	c.AuditOutput, err = c.runAudit(AuditCommand, c.Audit)
 	if err != nil {
 		return c.Audit, err
 	}
 
-	c.AuditConfigOutput, err = runAudit(c.AuditConfig)
+	if c.AuditConfigzOutput != "" {
+		c.Trace.addAudit(AuditConfigz, c.AuditConfigz, c.AuditConfigzOutput, nil, 0)
+	}
+
+	c.AuditConfigOutput, err = c.runAudit(AuditConfig, c.AuditConfig)
 	// when file not found then error comes as exit status 127
 	// in some env same error comes as exit status 1
 	if err != nil && (strings.Contains(err.Error(), "exit status 127") ||
@@ -221,6 +244,16 @@ func (c *Check) runAuditCommands() (lastCommand string, err error) {
 	return c.AuditConfig, err
 }
 
+// runAudit runs an audit command and records it in the check's trace.
+func (c *Check) runAudit(source AuditUsed, audit string) (string, error) {
+	start := time.Now()
+	output, err := runAudit(audit)
+	if strings.TrimSpace(audit) != "" {
+		c.Trace.addAudit(source, audit, output, err, time.Since(start))
+	}
+	return output, err
+}
+
 func (c *Check) execute() (finalOutput *testOutput, err error) {
 	finalOutput = &testOutput{}
 
@@ -232,29 +265,57 @@ func (c *Check) execute() (finalOutput *testOutput, err error) {
 	for i, t := range ts.TestItems {
 
 		t.isMultipleOutput = c.IsMultiple
+		tt := TestTrace{
+			Flag:         t.Flag,
+			Path:         t.Path,
+			Env:          t.Env,
+			Match:        t.Match,
+			Container:    t.Container,
+			Set:          t.Set,
+			Op:           t.Compare.Op,
+			CompareValue: t.Compare.Value,
+		}
+		try := func(auditUsed AuditUsed, output string) testOutput {
+			t.auditUsed = auditUsed
+			r := *(t.execute(output))
+			tt.Attempts = append(tt.Attempts, TestAttempt{
+				Source:         auditUsed,
+				Found:          r.flagFound,
+				Value:          r.value,
+				Result:         r.testResult,
+				ExpectedResult: r.ExpectedResult,
+			})
+			return r
+		}
 
 		// Try with the auditOutput first, and if that's not found, try the auditConfigOutput
-		t.auditUsed = AuditCommand
-		result := *(t.execute(c.AuditOutput))
+		result := try(AuditCommand, c.AuditOutput)
 
 		// Check for AuditConfigOutput only if AuditConfig is set and auditConfigOutput is not empty
 		if !result.flagFound && c.AuditConfig != "" && c.AuditConfigOutput != "" {
 			// t.isConfigSetting = true
-			t.auditUsed = AuditConfig
-			result = *(t.execute(c.AuditConfigOutput))
+			result = try(AuditConfig, c.AuditConfigOutput)
 			if !result.flagFound && t.Env != "" {
-				t.auditUsed = AuditEnv
-				result = *(t.execute(c.AuditEnvOutput))
+				result = try(AuditEnv, c.AuditEnvOutput)
 			}
 		}
 
+		// Then the configuration the component is actually running with, if it was read from its configz endpoint
+		if !result.flagFound && t.Path != "" && c.AuditConfigzOutput != "" {
+			result = try(AuditConfigz, c.AuditConfigzOutput)
+		}
+
 		if !result.flagFound && t.Env != "" {
-			t.auditUsed = AuditEnv
-			result = *(t.execute(c.AuditEnvOutput))
+			result = try(AuditEnv, c.AuditEnvOutput)
 		}
 		glog.V(2).Infof("Used %s", t.auditUsed)
 		res[i] = result
 		expectedResultArr[i] = res[i].ExpectedResult
+
+		tt.AuditUsed = t.auditUsed
+		tt.Result = result.testResult
+		tt.ExpectedResult = result.ExpectedResult
+		c.Trace.addTest(tt)
 	}
 
 	var result bool
@@ -283,6 +344,13 @@ func (c *Check) execute() (finalOutput *testOutput, err error) {
 
 	finalOutput.testResult = result
 	finalOutput.actualResult = res[0].actualResult
+	if c.Trace != nil {
+		c.Trace.BinOp = string(ts.BinOp)
+		if c.Trace.BinOp == "" {
+			c.Trace.BinOp = string(and)
+		}
+		c.Trace.BinOpResult = result
+	}
 
 	glog.V(3).Infof("Returning from execute on tests: finalOutput %#v", finalOutput)
 	return finalOutput, nil
@@ -304,7 +372,7 @@ func runAudit(audit string) (output string, err error) {
 	output = out.String()
 
 	if err != nil {
-		err = fmt.Errorf("failed to run: %q, output: %q, error: %s", audit, output, err)
+		err = fmt.Errorf("failed to run: %q, output: %q, error: %w", audit, output, err)
 	} else {
 		glog.V(3).Infof("Command: %q", audit)
 		glog.V(3).Infof("Output:\n %q", output)
@@ -17,6 +17,8 @@ package check
 import (
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCheck_Run(t *testing.T) {
@@ -182,6 +184,42 @@ func TestCheckAuditConfig(t *testing.T) {
 	}
 }
 
+func TestCheckAuditConfigz(t *testing.T) {
+	newCheck := func() *Check {
+		return &Check{
+			ID:                 "4.2.1",
+			Audit:              "echo 'kubelet --config=/var/lib/kubelet/config.yaml'",
+			AuditConfig:        "echo 'kind: KubeletConfiguration'",
+			AuditConfigz:       "/api/v1/nodes/node-1/proxy/configz",
+			AuditConfigzOutput: `{"kind": "KubeletConfiguration", "authentication": {"anonymous": {"enabled": false}}}`,
+			Scored:             true,
+			Tests: &tests{TestItems: []*testItem{
+				{Flag: "--anonymous-auth", Path: "{.authentication.anonymous.enabled}", Set: true, Compare: compare{Op: "eq", Value: "false"}},
+			}},
+		}
+	}
+
+	// The setting is in neither the command line nor the config file, so configz is used
+	c := newCheck()
+	assert.Equal(t, PASS, c.run())
+	assert.Equal(t, AuditConfigz, c.Trace.Tests[0].AuditUsed)
+	assert.Len(t, c.Trace.Tests[0].Attempts, 3)
+	assert.Equal(t, AuditConfigz, c.Trace.Audits[1].Source)
+	assert.Equal(t, "/api/v1/nodes/node-1/proxy/configz", c.Trace.Audits[1].Command)
+
+	// The config file takes precedence over configz
+	c = newCheck()
+	c.AuditConfig = "echo 'authentication: {anonymous: {enabled: true}}'"
+	assert.Equal(t, FAIL, c.run())
+	assert.Equal(t, AuditConfig, c.Trace.Tests[0].AuditUsed)
+
+	// Flag tests don't use configz
+	c = newCheck()
+	c.Tests.TestItems[0].Path = ""
+	assert.Equal(t, FAIL, c.run())
+	assert.NotEqual(t, AuditConfigz, c.Trace.Tests[0].AuditUsed)
+}
+
 func Test_runAudit(t *testing.T) {
 	type args struct {
 		audit  string
@@ -243,3 +281,66 @@ hello
 		})
 	}
 }
+
+func TestCheck_Trace(t *testing.T) {
+	c := &Check{
+		ID:          "1.2.3",
+		Audit:       "echo '--anonymous-auth=true'",
+		AuditConfig: "echo '{\"authentication\": {\"webhook\": {\"enabled\": true}}}'",
+		Scored:      true,
+		Tests: &tests{
+			BinOp: "",
+			TestItems: []*testItem{
+				{Flag: "--anonymous-auth", Set: true, Compare: compare{Op: "eq", Value: "false"}},
+				{Flag: "--authentication-token-webhook", Path: "{.authentication.webhook.enabled}", Set: true, Compare: compare{Op: "eq", Value: "true"}},
+			},
+		},
+	}
+
+	state := c.run()
+	assert.Equal(t, FAIL, state)
+	if !assert.NotNil(t, c.Trace) {
+		return
+	}
+	tr := c.Trace
+	assert.Equal(t, "1.2.3", tr.CheckID)
+	assert.Equal(t, FAIL, tr.State)
+	assert.Equal(t, "and", tr.BinOp)
+	assert.False(t, tr.BinOpResult)
+	assert.False(t, tr.End.Before(tr.Start))
+
+	assert.Len(t, tr.Audits, 2)
+	assert.Equal(t, AuditCommand, tr.Audits[0].Source)
+	assert.Equal(t, 0, tr.Audits[0].ExitStatus)
+	assert.Equal(t, "--anonymous-auth=true\n", tr.Audits[0].Output)
+	assert.Equal(t, AuditConfig, tr.Audits[1].Source)
+
+	assert.Len(t, tr.Tests, 2)
+	assert.Equal(t, AuditCommand, tr.Tests[0].AuditUsed)
+	assert.Equal(t, "true", tr.Tests[0].Attempts[0].Value)
+	assert.False(t, tr.Tests[0].Result)
+
+	// The flag is not in the audit output, so the config is used instead
+	assert.Equal(t, AuditConfig, tr.Tests[1].AuditUsed)
+	assert.Len(t, tr.Tests[1].Attempts, 2)
+	assert.False(t, tr.Tests[1].Attempts[0].Found)
+	assert.Equal(t, "true", tr.Tests[1].Attempts[1].Value)
+	assert.True(t, tr.Tests[1].Result)
+}
+
+func TestCheck_TraceExitStatus(t *testing.T) {
+	c := &Check{
+		ID:     "1.2.4",
+		Audit:  "exit 3",
+		Scored: true,
+		Tests:  &tests{TestItems: []*testItem{{Flag: "--profiling", Set: true}}},
+	}
+
+	c.run()
+	if assert.Len(t, c.Trace.Audits, 1) {
+		assert.Equal(t, 3, c.Trace.Audits[0].ExitStatus)
+		assert.NotEmpty(t, c.Trace.Audits[0].Error)
+	}
+	assert.Equal(t, FAIL, c.Trace.State)
+	assert.NotEmpty(t, c.Trace.Reason)
+}
@@ -18,7 +18,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -41,8 +44,10 @@ const (
 )
 
 type OverallControls struct {
-	Controls []*Controls
-	Totals   Summary
+	Controls  []*Controls
+	Totals    Summary
+	Metadata  *ReportMetadata  `json:"metadata,omitempty"`
+	Signature *ReportSignature `json:"signature,omitempty"`
 }
 
 // Controls holds all controls to check for master nodes.
@@ -50,6 +55,7 @@ type Controls struct {
 	ID              string   `yaml:"id" json:"id"`
 	Version         string   `json:"version"`
 	DetectedVersion string   `json:"detected_version,omitempty"`
+	VersionSource   string   `json:"detected_version_source,omitempty"`
 	Text            string   `json:"text"`
 	Type            NodeType `json:"node_type"`
 	Groups          []*Group `json:"tests"`
@@ -60,6 +66,7 @@ type Controls struct {
 type Group struct {
 	ID     string   `yaml:"id" json:"section"`
 	Type   string   `yaml:"type" json:"type"`
+	Tags   []string `yaml:"tags" json:"tags,omitempty"`
 	Pass   int      `json:"pass"`
 	Fail   int      `json:"fail"`
 	Warn   int      `json:"warn"`
@@ -91,10 +98,36 @@ func NewControls(t NodeType, in []byte, detectedVersion string) (*Controls, erro
 	if t != c.Type {
 		return nil, fmt.Errorf("non-%s controls file specified", t)
 	}
+
+	if err := c.compile(); err != nil {
+		return nil, err
+	}
 	c.DetectedVersion = detectedVersion
 	return c, nil
 }
 
+// compile sets the canonical ID of each check from its aliases, adds the tags of its group to
+// its own, validates all test items and compiles their regular expressions, reporting every
+// check that has an invalid one.
+func (controls *Controls) compile() error {
+	var errs []error
+	for _, group := range controls.Groups {
+		for _, check := range group.Checks {
+			check.setCanonicalID()
+			check.Tags = mergeTags(group.Tags, check.Tags)
+			if check.Tests == nil {
+				continue
+			}
+			for i, t := range check.Tests.TestItems {
+				if err := t.compile(); err != nil {
+					errs = append(errs, fmt.Errorf("check %s, test item %d: %v", check.ID, i+1, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // RunChecks runs the checks with the given Runner. Only checks for which the filter Predicate returns `true` will run.
 func (controls *Controls) RunChecks(runner Runner, filter Predicate, skipIDMap map[string]bool) Summary {
 	var g []*Group
@@ -109,7 +142,7 @@ func (controls *Controls) RunChecks(runner Runner, filter Predicate, skipIDMap m
 			}
 
 			_, groupSkippedViaCmd := skipIDMap[group.ID]
-			_, checkSkippedViaCmd := skipIDMap[check.ID]
+			checkSkippedViaCmd := check.MatchesAny(skipIDMap)
 
 			if group.Type == SKIP || groupSkippedViaCmd || checkSkippedViaCmd {
 				check.Type = SKIP
@@ -125,6 +158,7 @@ func (controls *Controls) RunChecks(runner Runner, filter Predicate, skipIDMap m
 				w := &Group{
 					ID:     group.ID,
 					Text:   group.Text,
+					Tags:   group.Tags,
 					Checks: []*Check{},
 				}
 
@@ -207,6 +241,17 @@ func (controls *Controls) JUnit() ([]byte, error) {
 
 // ASFF encodes the results of last run to AWS Security Finding Format(ASFF).
 func (controls *Controls) ASFF() ([]types.AwsSecurityFinding, error) {
+	return controls.asff(func(s State) bool { return s == FAIL || s == WARN }, false)
+}
+
+// ASFFResolved encodes the checks that passed in the last run to archived and
+// passed ASFF findings. Their Ids match the findings ASFF produces for the
+// same checks, so importing them closes findings left over from earlier runs.
+func (controls *Controls) ASFFResolved() ([]types.AwsSecurityFinding, error) {
+	return controls.asff(func(s State) bool { return s == PASS }, true)
+}
+
+func (controls *Controls) asff(include func(State) bool, resolved bool) ([]types.AwsSecurityFinding, error) {
 	fs := []types.AwsSecurityFinding{}
 	account, err := getConfig("AWS_ACCOUNT")
 	if err != nil {
@@ -227,7 +272,7 @@ func (controls *Controls) ASFF() ([]types.AwsSecurityFinding, error) {
 	tf := ti.Format(time.RFC3339)
 	for _, g := range controls.Groups {
 		for _, check := range g.Checks {
-			if check.State == FAIL || check.State == WARN {
+			if include(check.State) {
 				// ASFF ProductFields['Actual result'] can't be longer than 1024 characters
 				actualValue := check.ActualValue
 				remediation := check.Remediation
@@ -283,6 +328,15 @@ func (controls *Controls) ASFF() ([]types.AwsSecurityFinding, error) {
 							Type: aws.String(TYPE),
 						},
 					},
+					// An explicit record state reactivates a finding archived by an earlier run.
+					RecordState: types.RecordStateActive,
+				}
+				if len(check.Tags) > 0 {
+					f.ProductFields["Tags"] = strings.Join(check.Tags, ",")
+				}
+				if resolved {
+					f.RecordState = types.RecordStateArchived
+					f.Compliance = &types.Compliance{Status: types.ComplianceStatusPassed}
 				}
 				fs = append(fs, f)
 			}
@@ -328,3 +382,27 @@ func summarizeGroup(group *Group, state State) {
 		glog.Warningf("Unrecognized state %s", state)
 	}
 }
+
+// mergeTags returns the tags in a followed by those of b not in a.
+func mergeTags(a, b []string) []string {
+	if len(a) == 0 {
+		return b
+	}
+	tags := append([]string{}, a...)
+	for _, t := range b {
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// HasTag reports whether the check has any of the given tags.
+func (c *Check) HasTag(tags map[string]bool) bool {
+	for _, t := range c.Tags {
+		if tags[t] {
+			return true
+		}
+	}
+	return false
+}
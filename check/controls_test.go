@@ -64,6 +64,9 @@ func TestYamlFiles(t *testing.T) {
 			} else {
 				t.Fatalf("failed to load YAML from %s: %v", path, err)
 			}
+			if err := c.compile(); err != nil {
+				t.Fatalf("invalid test items in %s: %v", path, err)
+			}
 		}
 		return nil
 	})
@@ -96,6 +99,86 @@ groups:
 		assert.EqualError(t, err, "failed to unmarshal YAML: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `BOOM` into check.Controls")
 	})
 
+	t.Run("Should return error for every check with an invalid regex", func(t *testing.T) {
+		// given
+		in := []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: G1/C1
+    tests:
+      test_items:
+      - flag: "--tls-min-version"
+        compare:
+          op: regex
+          value: "^VersionTLS1[23$"
+  - id: G1/C2
+    tests:
+      test_items:
+      - flag: "--profiling"
+        compare:
+          op: regex
+          value: "^(false|true$"
+      - flag: "--authorization-mode"
+        compare:
+          op: regex
+          value: "RBAC"
+`)
+		// when
+		_, err := NewControls(MASTER, in, "")
+		// then
+		assert.EqualError(t, err, "check G1/C1, test item 1: invalid regex \"^VersionTLS1[23$\": error parsing regexp: missing closing ]: `[23$`\n"+
+			"check G1/C2, test item 1: invalid regex \"^(false|true$\": error parsing regexp: missing closing ): `^(false|true$`")
+	})
+
+	t.Run("Should return error for an invalid match", func(t *testing.T) {
+		// given
+		in := []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: G1/C1
+    tests:
+      test_items:
+      - path: "{.contexts[*].name}"
+        match: some
+`)
+		// when
+		_, err := NewControls(MASTER, in, "")
+		// then
+		assert.EqualError(t, err, "check G1/C1, test item 1: invalid match \"some\", expected \"any\" or \"all\"")
+	})
+
+	t.Run("Should add group tags to the tags of its checks", func(t *testing.T) {
+		// given
+		in := []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  tags: [api-server, tls]
+  checks:
+  - id: G1/C1
+    tags: [audit-logging, tls]
+  - id: G1/C2
+- id: G2
+  checks:
+  - id: G2/C1
+    tags: [rbac]
+`)
+		// when
+		controls, err := NewControls(MASTER, in, "")
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"api-server", "tls", "audit-logging"}, controls.Groups[0].Checks[0].Tags)
+		assert.Equal(t, []string{"api-server", "tls"}, controls.Groups[0].Checks[1].Tags)
+		assert.Equal(t, []string{"rbac"}, controls.Groups[1].Checks[0].Tags)
+	})
+
 }
 
 func TestControls_RunChecks_SkippedCmd(t *testing.T) {
@@ -400,6 +483,7 @@ func TestControls_ASFF(t *testing.T) {
 								Reason:         "failed",
 								ExpectedResult: "failed",
 								ActualValue:    "failed",
+								Tags:           []string{"tls", "audit-logging"},
 							},
 						},
 					},
@@ -428,6 +512,7 @@ func TestControls_ASFF(t *testing.T) {
 						"Expected result": "failed",
 						"Section":         fmt.Sprintf("%s %s", "test1", "test runnner"),
 						"Subsection":      fmt.Sprintf("%s %s", "g1", "Group text"),
+						"Tags":            "tls,audit-logging",
 					},
 					Resources: []types.Resource{
 						{
@@ -435,6 +520,7 @@ func TestControls_ASFF(t *testing.T) {
 							Type: aws.String(TYPE),
 						},
 					},
+					RecordState: types.RecordStateActive,
 				},
 			},
 			wantErr: false,
@@ -462,3 +548,40 @@ func TestControls_ASFF(t *testing.T) {
 		})
 	}
 }
+
+func TestControls_ASFFResolved(t *testing.T) {
+	viper.Set("AWS_ACCOUNT", "foo account")
+	viper.Set("CLUSTER_ARN", "foo Cluster")
+	viper.Set("AWS_REGION", "somewhere")
+	check := &Check{ID: "check1id", Text: "check1text", State: FAIL}
+	controls := &Controls{
+		ID:      "test1",
+		Version: "1",
+		Groups: []*Group{
+			{
+				ID: "g1",
+				Checks: []*Check{
+					check,
+					{ID: "check2id", State: WARN},
+				},
+			},
+		},
+	}
+
+	active, err := controls.ASFF()
+	assert.NoError(t, err)
+	resolved, err := controls.ASFFResolved()
+	assert.NoError(t, err)
+	assert.Empty(t, resolved)
+
+	// The check passes in a later run
+	check.State = PASS
+	resolved, err = controls.ASFFResolved()
+	assert.NoError(t, err)
+	assert.Len(t, resolved, 1)
+	assert.Equal(t, *active[0].Id, *resolved[0].Id)
+	assert.Equal(t, types.RecordStateArchived, resolved[0].RecordState)
+	// Security Hub ignores the workflow status imported by finding providers
+	assert.Nil(t, resolved[0].Workflow)
+	assert.Equal(t, types.ComplianceStatusPassed, resolved[0].Compliance.Status)
+}
@@ -0,0 +1,334 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CompileFilter compiles a filter expression into a Predicate. For example:
+//
+//	group in (1.2, 4.2) and scored and not id =~ "^5\\."
+//
+// Expressions combine, with and, or, not and parentheses, boolean fields and comparisons
+// of a field with a value: field == value, field != value, field =~ regex, field !~ regex
+// and field in (value, ...). Values are quoted strings or bare words such as 1.2.3.
+//
+// The fields are id, the check ID and its aliases; text, the check text; group and group_text,
+// the ID and text of the group of the check; type, the check type; and tags, the check tags.
+// A field with several values, such as id or tags, equals or matches a value if any of its
+// values does, so tags == tls selects the checks tagged tls and tags != tls the others.
+// The boolean fields are scored, and automated for checks that aren't manual.
+func CompileFilter(expr string) (Predicate, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return pred, nil
+}
+
+// stringFields are the fields compared with a value, returning the values of the field.
+var stringFields = map[string]func(*Group, *Check) []string{
+	"id":         func(g *Group, c *Check) []string { return append([]string{c.ID}, c.Aliases...) },
+	"text":       func(g *Group, c *Check) []string { return []string{c.Text} },
+	"group":      func(g *Group, c *Check) []string { return []string{g.ID} },
+	"group_text": func(g *Group, c *Check) []string { return []string{g.Text} },
+	"type":       func(g *Group, c *Check) []string { return []string{c.Type} },
+	"tags":       func(g *Group, c *Check) []string { return c.Tags },
+}
+
+// boolFields are the fields used as conditions on their own.
+var boolFields = map[string]Predicate{
+	"scored":    func(g *Group, c *Check) bool { return c.Scored },
+	"automated": func(g *Group, c *Check) bool { return isAutomated(c) },
+}
+
+// isAutomated reports whether a check isn't manual, by its type or its text.
+func isAutomated(c *Check) bool {
+	return c.Type != MANUAL && !strings.Contains(c.Text, "(Manual)")
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type filterToken struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// isWordRune reports whether r can be part of a bare word, such as a field name, a keyword or a check ID.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-:/*", r)
+}
+
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{kind: tokenComma, value: ",", pos: i})
+			i++
+		case r == '=' || r == '!':
+			if i+1 < len(runes) && (runes[i+1] == '=' || runes[i+1] == '~') {
+				tokens = append(tokens, filterToken{kind: tokenOp, value: string(runes[i : i+2]), pos: i})
+				i += 2
+				continue
+			}
+			return nil, fmt.Errorf("filter: unexpected %q at position %d", r, i+1)
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("filter: unterminated string at position %d", i+1)
+			}
+			s, err := strconv.Unquote(string(runes[i : j+1]))
+			if err != nil {
+				return nil, fmt.Errorf("filter: invalid string at position %d: %v", i+1, err)
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, value: s, pos: i})
+			i = j + 1
+		case r == '\'':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '\''; j++ {
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("filter: unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, value: string(runes[i+1 : j]), pos: i})
+			i = j + 1
+		case isWordRune(r):
+			j := i
+			for ; j < len(runes) && isWordRune(runes[j]); j++ {
+			}
+			tokens = append(tokens, filterToken{kind: tokenWord, value: string(runes[i:j]), pos: i})
+			i = j
+		default:
+			return nil, fmt.Errorf("filter: unexpected %q at position %d", r, i+1)
+		}
+	}
+	return append(tokens, filterToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// filterParser is a recursive descent parser of filter expressions:
+//
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" or ")" | comparison | boolField
+//	comparison = field ( "==" | "!=" | "=~" | "!~" ) value | field "in" "(" value { "," value } ")"
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) isKeyword(t filterToken, keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.value, keyword)
+}
+
+func (p *filterParser) errorf(t filterToken, format string, args ...interface{}) error {
+	return fmt.Errorf("filter: %s at position %d", fmt.Sprintf(format, args...), t.pos+1)
+}
+
+func (p *filterParser) parseOr() (Predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(g *Group, c *Check) bool { return l(g, c) || right(g, c) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(g *Group, c *Check) bool { return l(g, c) && right(g, c) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Predicate, error) {
+	t := p.next()
+	switch {
+	case p.isKeyword(t, "not"):
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Group, c *Check) bool { return !operand(g, c) }, nil
+	case t.kind == tokenLParen:
+		pred, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, p.errorf(r, "expected \")\", found %s", r)
+		}
+		return pred, nil
+	case t.kind == tokenWord:
+		name := strings.ToLower(t.value)
+		if pred, ok := boolFields[name]; ok {
+			return pred, nil
+		}
+		if field, ok := stringFields[name]; ok {
+			return p.parseComparison(name, field)
+		}
+		return nil, p.errorf(t, "unknown field %s", t)
+	default:
+		return nil, p.errorf(t, "expected a condition, found %s", t)
+	}
+}
+
+func (p *filterParser) parseComparison(name string, field func(*Group, *Check) []string) (Predicate, error) {
+	op := p.next()
+	if p.isKeyword(op, "in") {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Group, c *Check) bool {
+			return slices.ContainsFunc(field(g, c), func(f string) bool { return slices.Contains(values, f) })
+		}, nil
+	}
+	if op.kind != tokenOp {
+		return nil, p.errorf(op, "expected an operator after %s, found %s", name, op)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	switch op.value {
+	case "==":
+		return func(g *Group, c *Check) bool { return slices.Contains(field(g, c), value) }, nil
+	case "!=":
+		return func(g *Group, c *Check) bool { return !slices.Contains(field(g, c), value) }, nil
+	}
+
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, p.errorf(op, "invalid regular expression %q: %v", value, err)
+	}
+	matches := func(g *Group, c *Check) bool { return slices.ContainsFunc(field(g, c), re.MatchString) }
+	if op.value == "=~" {
+		return matches, nil
+	}
+	return func(g *Group, c *Check) bool { return !matches(g, c) }, nil
+}
+
+func (p *filterParser) parseValue() (string, error) {
+	t := p.next()
+	if t.kind != tokenWord && t.kind != tokenString {
+		return "", p.errorf(t, "expected a value, found %s", t)
+	}
+	return t.value, nil
+}
+
+func (p *filterParser) parseList() ([]string, error) {
+	if t := p.next(); t.kind != tokenLParen {
+		return nil, p.errorf(t, "expected \"(\" after in, found %s", t)
+	}
+	var values []string
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		t := p.next()
+		if t.kind == tokenRParen {
+			return values, nil
+		}
+		if t.kind != tokenComma {
+			return nil, p.errorf(t, "expected \",\" or \")\", found %s", t)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileFilter(t *testing.T) {
+	controls := &Controls{Groups: []*Group{
+		{ID: "1.2", Text: "API Server", Checks: []*Check{
+			{ID: "1.2.1", Text: "Ensure that the --anonymous-auth argument is set to false (Manual)", Scored: false, Tags: []string{"authentication"}},
+			{ID: "1.2.15", Text: "Ensure that the --profiling argument is set to false (Automated)", Scored: true, Aliases: []string{"apiserver-profiling"}, Tags: []string{"audit-logging"}},
+		}},
+		{ID: "4.2", Text: "Kubelet", Checks: []*Check{
+			{ID: "4.2.1", Text: "Ensure that the --anonymous-auth argument is set to false (Automated)", Scored: true, Tags: []string{"kubelet", "authentication"}},
+			{ID: "4.2.8", Text: "Ensure that the eventRecordQPS argument is set to a level which ensures appropriate event capture (Manual)", Type: MANUAL},
+		}},
+		{ID: "5.1", Text: "RBAC and Service Accounts", Checks: []*Check{
+			{ID: "5.1.1", Text: "Ensure that the cluster-admin role is only used where required (Automated)", Scored: true},
+			{ID: "5.1.2", Text: "Minimize access to secrets (Automated)", Type: SKIP},
+		}},
+	}}
+
+	testCases := []struct {
+		expr string
+		exp  []string
+	}{
+		{expr: `scored`, exp: []string{"1.2.15", "4.2.1", "5.1.1"}},
+		{expr: `group in (1.2,4.2) and scored and not id =~ "^5\\."`, exp: []string{"1.2.15", "4.2.1"}},
+		{expr: `automated and scored and not group_text =~ 'RBAC'`, exp: []string{"1.2.15", "4.2.1"}},
+		{expr: `automated`, exp: []string{"1.2.15", "4.2.1", "5.1.1", "5.1.2"}},
+		{expr: `id == 1.2.1 or id == apiserver-profiling`, exp: []string{"1.2.1", "1.2.15"}},
+		{expr: `id in (4.2.8, apiserver-profiling)`, exp: []string{"1.2.15", "4.2.8"}},
+		{expr: `id != 1.2.1 and group == "1.2"`, exp: []string{"1.2.15"}},
+		{expr: `text =~ "anonymous-auth" and not (group == 4.2 or scored)`, exp: []string{"1.2.1"}},
+		{expr: `type == manual or type == "skip"`, exp: []string{"4.2.8", "5.1.2"}},
+		{expr: `type == "" and id !~ "^1"`, exp: []string{"4.2.1", "5.1.1"}},
+		{expr: `not not scored and group == 5.1`, exp: []string{"5.1.1"}},
+		{expr: `scored or group == 1.2 and not scored`, exp: []string{"1.2.1", "1.2.15", "4.2.1", "5.1.1"}},
+		{expr: `SCORED AND Group == 4.2`, exp: []string{"4.2.1"}},
+		{expr: `tags == authentication`, exp: []string{"1.2.1", "4.2.1"}},
+		{expr: `tags != authentication and group in (1.2, 4.2)`, exp: []string{"1.2.15", "4.2.8"}},
+		{expr: `tags in (kubelet, audit-logging)`, exp: []string{"1.2.15", "4.2.1"}},
+		{expr: `tags =~ "^audit" or tags !~ "."`, exp: []string{"1.2.15", "4.2.8", "5.1.1", "5.1.2"}},
+		{expr: `id =~ "^apiserver-"`, exp: []string{"1.2.15"}},
+	}
+	for _, c := range testCases {
+		t.Run(c.expr, func(t *testing.T) {
+			pred, err := CompileFilter(c.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			var matched []string
+			for _, g := range controls.Groups {
+				for _, check := range g.Checks {
+					if pred(g, check) {
+						matched = append(matched, check.ID)
+					}
+				}
+			}
+			assert.Equal(t, c.exp, matched)
+		})
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	testCases := []struct {
+		expr   string
+		expErr string
+	}{
+		{expr: ``, expErr: "filter: expected a condition, found end of filter at position 1"},
+		{expr: `severity == high`, expErr: `filter: unknown field "severity" at position 1`},
+		{expr: `scored and`, expErr: "filter: expected a condition, found end of filter at position 11"},
+		{expr: `scored scored`, expErr: `filter: unexpected "scored" at position 8`},
+		{expr: `id 1.2.1`, expErr: `filter: expected an operator after id, found "1.2.1" at position 4`},
+		{expr: `id ==`, expErr: "filter: expected a value, found end of filter at position 6"},
+		{expr: `id = 1.2.1`, expErr: `filter: unexpected '=' at position 4`},
+		{expr: `group in 1.2`, expErr: `filter: expected "(" after in, found "1.2" at position 10`},
+		{expr: `group in (1.2 4.2)`, expErr: `filter: expected "," or ")", found "4.2" at position 15`},
+		{expr: `(scored`, expErr: `filter: expected ")", found end of filter at position 8`},
+		{expr: `text =~ "["`, expErr: `filter: invalid regular expression "["`},
+		{expr: `text == "abc`, expErr: "filter: unterminated string at position 9"},
+		{expr: `scored & automated`, expErr: `filter: unexpected '&' at position 8`},
+	}
+	for _, c := range testCases {
+		t.Run(c.expr, func(t *testing.T) {
+			_, err := CompileFilter(c.expr)
+			assert.ErrorContains(t, err, c.expErr)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// OCSFVersion is the version of the Open Cybersecurity Schema Framework the findings conform to.
+	OCSFVersion = "1.1.0"
+
+	ocsfCategoryFindings       = 2
+	ocsfClassComplianceFinding = 2003
+	ocsfActivityCreate         = 1
+	ocsfStatusNew              = 1
+
+	ocsfComplianceWarning = 2
+	ocsfComplianceFail    = 3
+
+	ocsfSeverityMedium = 3
+	ocsfSeverityHigh   = 4
+)
+
+// OCSFFinding is an OCSF Compliance Finding (class 2003) event.
+type OCSFFinding struct {
+	ActivityID   int    `json:"activity_id"`
+	ActivityName string `json:"activity_name"`
+	CategoryUID  int    `json:"category_uid"`
+	CategoryName string `json:"category_name"`
+	ClassUID     int    `json:"class_uid"`
+	ClassName    string `json:"class_name"`
+	TypeUID      int    `json:"type_uid"`
+	TypeName     string `json:"type_name"`
+	Time         int64  `json:"time"`
+	SeverityID   int    `json:"severity_id"`
+	Severity     string `json:"severity"`
+	StatusID     int    `json:"status_id"`
+	Status       string `json:"status"`
+	Message      string `json:"message"`
+
+	Metadata    OCSFMetadata      `json:"metadata"`
+	FindingInfo OCSFFindingInfo   `json:"finding_info"`
+	Compliance  OCSFCompliance    `json:"compliance"`
+	Remediation OCSFRemediation   `json:"remediation"`
+	Resources   []OCSFResource    `json:"resources"`
+	Unmapped    map[string]string `json:"unmapped,omitempty"`
+}
+
+// OCSFMetadata describes the event and the product that produced it.
+type OCSFMetadata struct {
+	Version string      `json:"version"`
+	Product OCSFProduct `json:"product"`
+	Labels  []string    `json:"labels,omitempty"`
+}
+
+// OCSFProduct identifies kube-bench as the reporting product.
+type OCSFProduct struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name"`
+	Version    string `json:"version,omitempty"`
+}
+
+// OCSFFindingInfo holds the identity and description of a finding.
+type OCSFFindingInfo struct {
+	UID         string   `json:"uid"`
+	Title       string   `json:"title"`
+	Desc        string   `json:"desc,omitempty"`
+	Types       []string `json:"types"`
+	CreatedTime int64    `json:"created_time"`
+}
+
+// OCSFCompliance holds the benchmark control a finding was raised for.
+type OCSFCompliance struct {
+	Standards    []string `json:"standards"`
+	Control      string   `json:"control"`
+	Requirements []string `json:"requirements,omitempty"`
+	StatusID     int      `json:"status_id"`
+	Status       string   `json:"status"`
+	StatusDetail string   `json:"status_detail,omitempty"`
+}
+
+// OCSFRemediation describes how to fix a finding.
+type OCSFRemediation struct {
+	Desc string `json:"desc"`
+}
+
+// OCSFResource is a resource a finding applies to.
+type OCSFResource struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// OCSF encodes the results of last run to OCSF Compliance Finding events.
+// productVersion is the version of kube-bench reported in the event metadata.
+func (controls *Controls) OCSF(productVersion string) ([]OCSFFinding, error) {
+	fs := []OCSFFinding{}
+
+	var resources []OCSFResource
+	if cluster := viper.GetString("CLUSTER_NAME"); cluster != "" {
+		resources = append(resources, OCSFResource{Type: "Kubernetes Cluster", Name: cluster})
+	}
+	node := viper.GetString("NODE_NAME")
+	if node == "" {
+		var err error
+		node, err = os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname: %v", err)
+		}
+	}
+	resources = append(resources, OCSFResource{Type: "Kubernetes Node", Name: node})
+
+	standard := fmt.Sprintf("CIS Kubernetes Benchmark %s", controls.Version)
+	ts := time.Now().UnixMilli()
+	for _, g := range controls.Groups {
+		for _, check := range g.Checks {
+			if check.State != FAIL && check.State != WARN {
+				continue
+			}
+
+			f := OCSFFinding{
+				ActivityID:   ocsfActivityCreate,
+				ActivityName: "Create",
+				CategoryUID:  ocsfCategoryFindings,
+				CategoryName: "Findings",
+				ClassUID:     ocsfClassComplianceFinding,
+				ClassName:    "Compliance Finding",
+				TypeUID:      ocsfClassComplianceFinding*100 + ocsfActivityCreate,
+				TypeName:     "Compliance Finding: Create",
+				Time:         ts,
+				StatusID:     ocsfStatusNew,
+				Status:       "New",
+				Message:      fmt.Sprintf("%s %s", check.ID, check.Text),
+				Metadata: OCSFMetadata{
+					Version: OCSFVersion,
+					Product: OCSFProduct{
+						Name:       "kube-bench",
+						VendorName: "Aqua Security",
+						Version:    productVersion,
+					},
+					Labels: check.Tags,
+				},
+				FindingInfo: OCSFFindingInfo{
+					UID:         fmt.Sprintf("kube-bench/%s/%s/%s", controls.Version, node, check.ID),
+					Title:       fmt.Sprintf("%s %s", check.ID, check.Text),
+					Desc:        check.Text,
+					Types:       []string{TYPE},
+					CreatedTime: ts,
+				},
+				Compliance: OCSFCompliance{
+					Standards:    []string{standard},
+					Control:      check.ID,
+					Requirements: []string{check.ExpectedResult},
+					StatusDetail: check.Reason,
+				},
+				Remediation: OCSFRemediation{Desc: check.Remediation},
+				Resources:   resources,
+				Unmapped: map[string]string{
+					"actual_value": check.ActualValue,
+					"section":      fmt.Sprintf("%s %s", controls.ID, controls.Text),
+					"subsection":   fmt.Sprintf("%s %s", g.ID, g.Text),
+				},
+			}
+			if check.ExpectedResult == "" {
+				f.Compliance.Requirements = nil
+			}
+
+			if check.State == FAIL {
+				f.SeverityID, f.Severity = ocsfSeverityHigh, "High"
+				f.Compliance.StatusID, f.Compliance.Status = ocsfComplianceFail, "Fail"
+			} else {
+				f.SeverityID, f.Severity = ocsfSeverityMedium, "Medium"
+				f.Compliance.StatusID, f.Compliance.Status = ocsfComplianceWarning, "Warning"
+			}
+
+			fs = append(fs, f)
+		}
+	}
+	return fs, nil
+}
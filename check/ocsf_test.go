@@ -0,0 +1,79 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControls_OCSF(t *testing.T) {
+	viper.Set("CLUSTER_NAME", "prod")
+	viper.Set("NODE_NAME", "node-1")
+	defer viper.Set("CLUSTER_NAME", "")
+	defer viper.Set("NODE_NAME", "")
+
+	controls := &Controls{
+		ID:      "1",
+		Version: "cis-1.9",
+		Text:    "Control Plane Security Configuration",
+		Groups: []*Group{
+			{
+				ID:   "1.2",
+				Text: "API Server",
+				Checks: []*Check{
+					{ID: "1.2.1", Text: "anonymous auth", State: PASS},
+					{
+						ID:             "1.2.2",
+						Text:           "token auth",
+						State:          FAIL,
+						Remediation:    "remove --token-auth-file",
+						ExpectedResult: "'--token-auth-file' is not present",
+						ActualValue:    "--token-auth-file=/tokens",
+						Tags:           []string{"api-server", "authentication"},
+					},
+					{ID: "1.2.3", Text: "manual", State: WARN, Reason: "Test marked as a manual test"},
+				},
+			},
+		},
+	}
+
+	fs, err := controls.OCSF("v0.0.1")
+	assert.NoError(t, err)
+	assert.Len(t, fs, 2)
+
+	f := fs[0]
+	assert.Equal(t, 2003, f.ClassUID)
+	assert.Equal(t, 200301, f.TypeUID)
+	assert.Equal(t, "v0.0.1", f.Metadata.Product.Version)
+	assert.Equal(t, "1.2.2", f.Compliance.Control)
+	assert.Equal(t, []string{"CIS Kubernetes Benchmark cis-1.9"}, f.Compliance.Standards)
+	assert.Equal(t, []string{"'--token-auth-file' is not present"}, f.Compliance.Requirements)
+	assert.Equal(t, "Fail", f.Compliance.Status)
+	assert.Equal(t, "High", f.Severity)
+	assert.Equal(t, "remove --token-auth-file", f.Remediation.Desc)
+	assert.Equal(t, []OCSFResource{{Type: "Kubernetes Cluster", Name: "prod"}, {Type: "Kubernetes Node", Name: "node-1"}}, f.Resources)
+	assert.Equal(t, "--token-auth-file=/tokens", f.Unmapped["actual_value"])
+	assert.Equal(t, []string{"api-server", "authentication"}, f.Metadata.Labels)
+
+	f = fs[1]
+	assert.Equal(t, "1.2.3", f.Compliance.Control)
+	assert.Equal(t, "Warning", f.Compliance.Status)
+	assert.Equal(t, "Test marked as a manual test", f.Compliance.StatusDetail)
+	assert.Nil(t, f.Compliance.Requirements)
+	assert.Nil(t, f.Metadata.Labels)
+}
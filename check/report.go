@@ -0,0 +1,126 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SignatureAlgorithm is the algorithm of report signatures.
+const SignatureAlgorithm = "ed25519"
+
+// signatureKey introduces the signature, the last member of a signed report.
+var signatureKey = []byte(`,"signature":`)
+
+// ReportMetadata describes the scan that produced a report.
+type ReportMetadata struct {
+	Host             string `json:"host"`
+	Time             string `json:"time"`
+	Benchmark        string `json:"benchmark"`
+	KubeBenchVersion string `json:"kube_bench_version"`
+	// ConfigFiles maps the path of each config and controls file read to its SHA-256 hash.
+	ConfigFiles  map[string]string `json:"config_files"`
+	BinarySHA256 string            `json:"binary_sha256"`
+}
+
+// ReportSignature is the signature of a report, made over the JSON of the report that precedes it.
+type ReportSignature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	Value     string `json:"value"`
+}
+
+// KeyID returns the ID of a public key, the hex SHA-256 hash of its PKIX encoding.
+func KeyID(key ed25519.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SignReport signs the JSON of a report, a JSON object, and returns it with the signature added
+// as its last member.
+func SignReport(report []byte, key ed25519.PrivateKey) ([]byte, error) {
+	report = bytes.TrimSpace(report)
+	if len(report) < 2 || report[0] != '{' || report[len(report)-1] != '}' {
+		return nil, errors.New("report isn't a JSON object")
+	}
+	keyID, err := KeyID(key.Public().(ed25519.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	sig, err := json.Marshal(ReportSignature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     keyID,
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, report)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signed := append([]byte{}, report[:len(report)-1]...)
+	signed = append(signed, signatureKey...)
+	signed = append(signed, sig...)
+	return append(signed, '}'), nil
+}
+
+// VerifyReport checks the signature of a signed report with key, and returns the report.
+func VerifyReport(signed []byte, key ed25519.PublicKey) (*OverallControls, error) {
+	signed = bytes.TrimSpace(signed)
+	i := bytes.LastIndex(signed, signatureKey)
+	if i < 0 || signed[len(signed)-1] != '}' {
+		return nil, errors.New("report isn't signed")
+	}
+	report := append(append([]byte{}, signed[:i]...), '}')
+
+	var sig ReportSignature
+	if err := json.Unmarshal(signed[i+len(signatureKey):len(signed)-1], &sig); err != nil {
+		return nil, fmt.Errorf("invalid report signature: %v", err)
+	}
+	if sig.Algorithm != SignatureAlgorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+	keyID, err := KeyID(key)
+	if err != nil {
+		return nil, err
+	}
+	if sig.KeyID != keyID {
+		return nil, fmt.Errorf("report was signed with key %s, not %s", sig.KeyID, keyID)
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report signature: %v", err)
+	}
+	if !ed25519.Verify(key, report, value) {
+		return nil, errors.New("signature doesn't match the report, which was modified after it was signed")
+	}
+
+	var overall OverallControls
+	if err := json.Unmarshal(report, &overall); err != nil {
+		return nil, fmt.Errorf("invalid report: %v", err)
+	}
+	overall.Signature = &sig
+	return &overall, nil
+}
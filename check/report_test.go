@@ -0,0 +1,74 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignReport(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	report, err := json.Marshal(OverallControls{
+		Controls: []*Controls{{ID: "4", Version: "cis-1.12", Groups: []*Group{{ID: "4.1", Checks: []*Check{{ID: "4.1.1", State: FAIL}}}}}},
+		Totals:   Summary{Fail: 1},
+		Metadata: &ReportMetadata{Host: "node-1", Benchmark: "cis-1.12", ConfigFiles: map[string]string{"cfg/cis-1.12/node.yaml": "abc"}},
+	})
+	assert.NoError(t, err)
+
+	signed, err := SignReport(append(report, '\n'), key)
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(signed))
+	assert.True(t, bytes.HasPrefix(signed, report[:len(report)-1]))
+	assert.Contains(t, string(signed), `,"signature":{"algorithm":"ed25519","key_id":"`)
+
+	overall, err := VerifyReport(append(bytes.Clone(signed), '\n'), pub)
+	assert.NoError(t, err)
+	assert.Equal(t, "node-1", overall.Metadata.Host)
+	assert.Equal(t, FAIL, overall.Controls[0].Groups[0].Checks[0].State)
+	keyID, err := KeyID(pub)
+	assert.NoError(t, err)
+	assert.Equal(t, keyID, overall.Signature.KeyID)
+
+	testCases := []struct {
+		name   string
+		report []byte
+		key    ed25519.PublicKey
+		expErr string
+	}{
+		{name: "modified", report: bytes.Replace(signed, []byte(`"FAIL"`), []byte(`"PASS"`), 1), key: pub, expErr: "signature doesn't match the report"},
+		{name: "signed with another key", report: signed, key: otherPub, expErr: "report was signed with key " + keyID},
+		{name: "unsigned", report: report, key: pub, expErr: "report isn't signed"},
+		{name: "content after the signature", report: append(bytes.Clone(signed[:len(signed)-1]), `,"Totals":{}}`...), key: pub, expErr: "invalid report signature"},
+		{name: "unknown algorithm", report: bytes.Replace(signed, []byte(`"ed25519"`), []byte(`"rsa"`), 1), key: pub, expErr: `unsupported signature algorithm "rsa"`},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := VerifyReport(c.report, c.key)
+			assert.ErrorContains(t, err, c.expErr)
+		})
+	}
+
+	_, err = SignReport([]byte(`[{"id":"4"}]`), key)
+	assert.EqualError(t, err, "report isn't a JSON object")
+}
@@ -19,12 +19,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/golang/glog"
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/client-go/util/jsonpath"
 )
 
@@ -32,8 +37,12 @@ import (
 // flag: OPTION
 // set: (true|false)
 // compare:
-//   op: (eq|gt|gte|lt|lte|has)
+//   op: (eq|gt|gte|lt|lte|has|semver_gte|duration_gte|quantity_lte|...)
 //   value: val
+//   order: [val1, val2, ...] # optional ordering of values for gt|gte|lt|lte
+//   separator: sep # optional separator of list values, defaults to ","
+// match: (any|all) # optional, evaluates path against every document and value
+// container: name # optional, evaluates path against the named container of a Pod
 
 type binOp string
 
@@ -41,6 +50,9 @@ const (
 	and                   binOp = "and"
 	or                          = "or"
 	defaultArraySeparator       = ","
+
+	matchAny = "any"
+	matchAll = "all"
 )
 
 type tests struct {
@@ -54,15 +66,22 @@ const (
 	AuditCommand AuditUsed = "auditCommand"
 	AuditConfig  AuditUsed = "auditConfig"
 	AuditEnv     AuditUsed = "auditEnv"
+	AuditConfigz AuditUsed = "auditConfigz"
 )
 
 type testItem struct {
-	Flag             string
-	Env              string
-	Path             string
-	Output           string
-	Value            string
-	Set              bool
+	Flag   string
+	Env    string
+	Path   string
+	Output string
+	Value  string
+	Set    bool
+	// Match evaluates the path against every document of the audit_config output,
+	// and every value the path selects in them. With any, one passing value is enough;
+	// with all, every value must pass.
+	Match string
+	// Container evaluates the path against the containers of that name in a Pod manifest.
+	Container        string
 	Compare          compare
 	isMultipleOutput bool
 	auditUsed        AuditUsed
@@ -77,11 +96,18 @@ type (
 type compare struct {
 	Op    string
 	Value string
+	// Order lists the possible values from lowest to highest. When set,
+	// gt, gte, lt and lte compare positions in this list instead of numbers.
+	Order []string
+	// Separator splits the flag value into elements for the set operations.
+	// It defaults to a comma.
+	Separator string
 }
 
 type testOutput struct {
 	testResult     bool
 	flagFound      bool
+	value          string
 	actualResult   string
 	ExpectedResult string
 }
@@ -91,7 +117,7 @@ func failTestItem(s string) *testOutput {
 }
 
 func (t testItem) value() string {
-	if t.auditUsed == AuditConfig {
+	if t.auditUsed == AuditConfig || t.auditUsed == AuditConfigz {
 		return t.Path
 	}
 
@@ -108,7 +134,7 @@ func (t testItem) findValue(s string) (match bool, value string, err error) {
 		return et.findValue(s)
 	}
 
-	if t.auditUsed == AuditConfig {
+	if t.auditUsed == AuditConfig || t.auditUsed == AuditConfigz {
 		pt := pathTestItem(t)
 		return pt.findValue(s)
 	}
@@ -121,18 +147,32 @@ func (t flagTestItem) findValue(s string) (match bool, value string, err error)
 	if s == "" || t.Flag == "" {
 		return
 	}
+	// Command-line flags are looked up by name in the parsed arguments, so that
+	// --flag doesn't match --flag-other. A flag given with a value, such as
+	// --rotate-certificates=false, only matches when it's set to that value.
+	if strings.HasPrefix(t.Flag, "-") {
+		name, want, hasValue := strings.Cut(t.Flag, "=")
+		value, match = parseFlags(s)[name]
+		if hasValue {
+			match = match && value == want
+		}
+		glog.V(3).Infof("In flagTestItem.findValue %s", value)
+		return match, value, nil
+	}
+
 	match = strings.Contains(s, t.Flag)
 	if match {
 		// Expects flags in the form;
-		// --flag=somevalue
+		// flag=somevalue
 		// flag: somevalue
-		// --flag
 		// somevalue
 		// DOESN'T COVER - use pathTestItem implementation of findValue() for this
 		// flag:
 		//	 - wehbook
-		pttn := `(` + t.Flag + `)(=|: *)*([^\s]*) *`
-		flagRe := regexp.MustCompile(pttn)
+		flagRe, err := compileRegex(flagPattern(t.Flag))
+		if err != nil {
+			return false, "", fmt.Errorf("invalid flag in testItem definition: %s: %v", t.Flag, err)
+		}
 		vals := flagRe.FindStringSubmatch(s)
 
 		if len(vals) > 0 {
@@ -173,9 +213,86 @@ func (t pathTestItem) findValue(s string) (match bool, value string, err error)
 	return match, value, err
 }
 
+// findValues returns every value the path selects in each document of s.
+// If a Container is given, the path is evaluated against each container of that name.
+func (t pathTestItem) findValues(s string) (values []string, err error) {
+	for _, doc := range splitDocuments(s) {
+		var jsonInterface interface{}
+		err = unmarshal(doc, &jsonInterface)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load YAML or JSON from input \"%s\": %v", doc, err)
+		}
+
+		roots := []interface{}{jsonInterface}
+		if t.Container != "" {
+			roots, err = findContainers(jsonInterface, t.Container)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, root := range roots {
+			v, err := executeJSONPathValues(t.Path, root)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse path expression \"%s\": %v", t.Path, err)
+			}
+			values = append(values, v...)
+		}
+	}
+
+	glog.V(3).Infof("In pathTestItem.findValues %q", values)
+	return values, nil
+}
+
+// containerPaths select containers by name in a Pod, or in the Pod template of a workload.
+var containerPaths = []string{
+	`{.spec.containers[?(@.name=="%s")]}`,
+	`{.spec.initContainers[?(@.name=="%s")]}`,
+	`{.spec.template.spec.containers[?(@.name=="%s")]}`,
+	`{.spec.template.spec.initContainers[?(@.name=="%s")]}`,
+}
+
+func findContainers(jsonInterface interface{}, name string) ([]interface{}, error) {
+	var containers []interface{}
+	for _, path := range containerPaths {
+		j := jsonpath.New("container")
+		j.AllowMissingKeys(true)
+		err := j.Parse(fmt.Sprintf(path, name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid container name %q: %v", name, err)
+		}
+		results, err := j.FindResults(jsonInterface)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			for _, v := range r {
+				containers = append(containers, v.Interface())
+			}
+		}
+	}
+	return containers, nil
+}
+
+// splitDocuments splits s into its YAML documents, skipping empty ones.
+func splitDocuments(s string) []string {
+	var docs []string
+	for _, doc := range documentSeparator.Split(s, -1) {
+		if strings.TrimSpace(doc) != "" {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
 func (t envTestItem) findValue(s string) (match bool, value string, err error) {
 	if s != "" && t.Env != "" {
-		r, _ := regexp.Compile(fmt.Sprintf("%s=.*(?:$|\\n)", t.Env))
+		r, err := compileRegex(envPattern(t.Env))
+		if err != nil {
+			return false, "", fmt.Errorf("invalid env in testItem definition: %s: %v", t.Env, err)
+		}
 		out := r.FindString(s)
 		out = strings.Replace(out, "\n", "", 1)
 		out = strings.Replace(out, fmt.Sprintf("%s=", t.Env), "", 1)
@@ -192,6 +309,58 @@ func (t envTestItem) findValue(s string) (match bool, value string, err error) {
 	return match, value, nil
 }
 
+// compile validates the test item, and compiles the regular expressions it uses
+// ahead of its evaluation.
+func (t testItem) compile() error {
+	if t.Flag != "" {
+		if _, err := compileRegex(flagPattern(t.Flag)); err != nil {
+			return fmt.Errorf("invalid flag %q: %v", t.Flag, err)
+		}
+	}
+	if t.Env != "" {
+		if _, err := compileRegex(envPattern(t.Env)); err != nil {
+			return fmt.Errorf("invalid env %q: %v", t.Env, err)
+		}
+	}
+	switch t.Match {
+	case "", matchAny, matchAll:
+	default:
+		return fmt.Errorf("invalid match %q, expected %q or %q", t.Match, matchAny, matchAll)
+	}
+	if t.Compare.Op == "regex" {
+		if _, err := compileRegex(t.Compare.Value); err != nil {
+			return fmt.Errorf("invalid regex %q: %v", t.Compare.Value, err)
+		}
+	}
+	return nil
+}
+
+// regexCache holds compiled regular expressions, keyed by their pattern.
+var regexCache sync.Map
+
+// compileRegex compiles pattern, reusing an earlier compilation of the same pattern.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
+// flagPattern matches flag, taken literally, and its value.
+func flagPattern(flag string) string {
+	return `(` + regexp.QuoteMeta(flag) + `)(=|: *)*([^\s\x00]*) *`
+}
+
+// envPattern matches the environment variable env, taken literally, and its value.
+func envPattern(env string) string {
+	return regexp.QuoteMeta(env) + `=.*(?:$|\n)`
+}
+
 func (t testItem) execute(s string) *testOutput {
 	result := &testOutput{}
 	s = strings.TrimRight(s, " \n")
@@ -212,12 +381,15 @@ func (t testItem) execute(s string) *testOutput {
 		}
 	}
 
-	result.actualResult = s
+	// The NUL-separated arguments of a command line are shown as ps shows them
+	result.actualResult = strings.ReplaceAll(strings.TrimRight(s, "\x00"), "\x00", " ")
 	return result
 }
 
 func (t testItem) evaluate(s string) *testOutput {
-	result := &testOutput{}
+	if (t.auditUsed == AuditConfig || t.auditUsed == AuditConfigz) && (t.Match != "" || t.Container != "") {
+		return t.evaluateValues(s)
+	}
 
 	match, value, err := t.findValue(s)
 	if err != nil {
@@ -225,8 +397,48 @@ func (t testItem) evaluate(s string) *testOutput {
 		return failTestItem(err.Error())
 	}
 
+	return t.evaluateValue(match, value)
+}
+
+// evaluateValues evaluates each value the path selects in s, as set by Match.
+// Without a Match, a test item with a Container requires all values to pass.
+func (t testItem) evaluateValues(s string) *testOutput {
+	values, err := pathTestItem(t).findValues(s)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err.Error())
+		return failTestItem(err.Error())
+	}
+
+	all := t.Match != matchAny
+	var result *testOutput
+	for _, value := range values {
+		result = t.evaluateValue(value != "", value)
+		// Stop at the first failing value for all, and the first passing value for any
+		if result.testResult != all {
+			break
+		}
+	}
+	if result == nil {
+		result = t.evaluateValue(false, "")
+	}
+
+	quantifier := matchAll
+	if !all {
+		quantifier = matchAny
+	}
+	result.ExpectedResult += fmt.Sprintf(" for %s of %d values", quantifier, len(values))
+	return result
+}
+
+func (t testItem) evaluateValue(match bool, value string) *testOutput {
+	result := &testOutput{}
+
 	if t.Set {
-		if match && t.Compare.Op != "" {
+		if match && t.Compare.Op != "" && len(t.Compare.Order) > 0 {
+			result.ExpectedResult, result.testResult = compareOrderOp(t.Compare.Op, value, t.Compare.Value, t.Compare.Order, t.value())
+		} else if match && t.Compare.Op != "" && t.Compare.Separator != "" {
+			result.ExpectedResult, result.testResult = compareSetOp(t.Compare.Op, value, t.Compare.Value, t.Compare.Separator, t.value())
+		} else if match && t.Compare.Op != "" {
 			result.ExpectedResult, result.testResult = compareOp(t.Compare.Op, value, t.Compare.Value, t.value())
 		} else {
 			result.ExpectedResult = fmt.Sprintf("'%s' is present", t.value())
@@ -238,6 +450,7 @@ func (t testItem) evaluate(s string) *testOutput {
 	}
 
 	result.flagFound = match
+	result.value = value
 	isExist := "exists"
 	if !result.flagFound {
 		isExist = "does not exist"
@@ -245,7 +458,7 @@ func (t testItem) evaluate(s string) *testOutput {
 	switch t.auditUsed {
 	case AuditCommand:
 		glog.V(3).Infof("Flag '%s' %s", t.Flag, isExist)
-	case AuditConfig:
+	case AuditConfig, AuditConfigz:
 		glog.V(3).Infof("Path '%s' %s", t.Path, isExist)
 	case AuditEnv:
 		glog.V(3).Infof("Env '%s' %s", t.Env, isExist)
@@ -306,6 +519,41 @@ func compareOp(tCompareOp string, flagVal string, tCompareValue string, flagName
 			testResult = a <= b
 		}
 
+	case "semver_gt", "semver_gte", "semver_lt", "semver_lte":
+		a, err := version.ParseGeneric(flagVal)
+		if err != nil {
+			glog.V(1).Infof("Not a version - flag: %q - compareValue: %q %v\n", flagVal, tCompareValue, err)
+			return fmt.Sprintf("Invalid version(s) used for comparison: '%s' '%s'", flagVal, tCompareValue), false
+		}
+		cmp, err := a.Compare(tCompareValue)
+		if err != nil {
+			glog.V(1).Infof("Not a version - flag: %q - compareValue: %q %v\n", flagVal, tCompareValue, err)
+			return fmt.Sprintf("Invalid version(s) used for comparison: '%s' '%s'", flagVal, tCompareValue), false
+		}
+		expectedResultPattern, testResult = compareResult(strings.TrimPrefix(tCompareOp, "semver_"), "version", cmp)
+
+	case "duration_gt", "duration_gte", "duration_lt", "duration_lte":
+		a, b, err := toDuration(flagVal, tCompareValue)
+		if err != nil {
+			glog.V(1).Infof("Not a duration - flag: %q - compareValue: %q %v\n", flagVal, tCompareValue, err)
+			return fmt.Sprintf("Invalid duration(s) used for comparison: '%s' '%s'", flagVal, tCompareValue), false
+		}
+		cmp := 0
+		if a < b {
+			cmp = -1
+		} else if a > b {
+			cmp = 1
+		}
+		expectedResultPattern, testResult = compareResult(strings.TrimPrefix(tCompareOp, "duration_"), "duration", cmp)
+
+	case "quantity_gt", "quantity_gte", "quantity_lt", "quantity_lte":
+		a, b, err := toQuantity(flagVal, tCompareValue)
+		if err != nil {
+			glog.V(1).Infof("Not a quantity - flag: %q - compareValue: %q %v\n", flagVal, tCompareValue, err)
+			return fmt.Sprintf("Invalid quantity(s) used for comparison: '%s' '%s'", flagVal, tCompareValue), false
+		}
+		expectedResultPattern, testResult = compareResult(strings.TrimPrefix(tCompareOp, "quantity_"), "quantity", a.Cmp(b))
+
 	case "has":
 		expectedResultPattern = "'%s' has '%s'"
 		testResult = strings.Contains(flagVal, tCompareValue)
@@ -316,7 +564,11 @@ func compareOp(tCompareOp string, flagVal string, tCompareValue string, flagName
 
 	case "regex":
 		expectedResultPattern = "'%s' matched by regex expression '%s'"
-		opRe := regexp.MustCompile(tCompareValue)
+		opRe, err := compileRegex(tCompareValue)
+		if err != nil {
+			glog.V(1).Infof("Invalid regex - compareValue: %q %v\n", tCompareValue, err)
+			return fmt.Sprintf("Invalid regex used for comparison: '%s'", tCompareValue), false
+		}
 		testResult = opRe.MatchString(flagVal)
 
 	case "valid_elements":
@@ -325,6 +577,9 @@ func compareOp(tCompareOp string, flagVal string, tCompareValue string, flagName
 		target := splitAndRemoveLastSeparator(tCompareValue, defaultArraySeparator)
 		testResult = allElementsValid(s, target)
 
+	case "contains_all", "contains_any", "contains_none", "equals_set":
+		return compareSetOp(tCompareOp, flagVal, tCompareValue, defaultArraySeparator, flagName)
+
 	case "bitmask":
 		expectedResultPattern = "%s has permissions " + flagVal + ", expected %s or more restrictive"
 		requested, err := strconv.ParseInt(flagVal, 8, 64)
@@ -346,6 +601,165 @@ func compareOp(tCompareOp string, flagVal string, tCompareValue string, flagName
 	return fmt.Sprintf(expectedResultPattern, flagName, tCompareValue), testResult
 }
 
+// compareResult returns the expected result pattern and the outcome of op,
+// one of gt, gte, lt or lte, given the result cmp of comparing two values of the given kind.
+func compareResult(op string, kind string, cmp int) (string, bool) {
+	switch op {
+	case "gt":
+		return "'%s' is a " + kind + " greater than %s", cmp > 0
+	case "gte":
+		return "'%s' is a " + kind + " greater or equal to %s", cmp >= 0
+	case "lt":
+		return "'%s' is a " + kind + " lower than %s", cmp < 0
+	case "lte":
+		return "'%s' is a " + kind + " lower or equal to %s", cmp <= 0
+	}
+	return "", false
+}
+
+// compareSetOp compares the elements of flagVal, split by separator, with the
+// comma-separated elements of tCompareValue.
+func compareSetOp(tCompareOp string, flagVal string, tCompareValue string, separator string, flagName string) (string, bool) {
+	expected := splitElements(tCompareValue, defaultArraySeparator)
+	actual := splitElements(flagVal, separator)
+	missing := difference(expected, actual)
+	extra := difference(actual, expected)
+	present := difference(expected, missing)
+
+	expectedResult := ""
+	testResult := false
+
+	switch tCompareOp {
+	case "contains_all":
+		expectedResult = fmt.Sprintf("'%s' contains all of '%s'", flagName, tCompareValue)
+		testResult = len(missing) == 0
+		if !testResult {
+			expectedResult += fmt.Sprintf(", missing '%s'", strings.Join(missing, defaultArraySeparator))
+		}
+
+	case "contains_any":
+		expectedResult = fmt.Sprintf("'%s' contains any of '%s'", flagName, tCompareValue)
+		testResult = len(present) > 0
+		if !testResult {
+			expectedResult += ", none found"
+		}
+
+	case "contains_none":
+		expectedResult = fmt.Sprintf("'%s' contains none of '%s'", flagName, tCompareValue)
+		testResult = len(present) == 0
+		if !testResult {
+			expectedResult += fmt.Sprintf(", forbidden '%s'", strings.Join(present, defaultArraySeparator))
+		}
+
+	case "equals_set":
+		expectedResult = fmt.Sprintf("'%s' is the set '%s'", flagName, tCompareValue)
+		testResult = len(missing) == 0 && len(extra) == 0
+		if len(missing) > 0 {
+			expectedResult += fmt.Sprintf(", missing '%s'", strings.Join(missing, defaultArraySeparator))
+		}
+		if len(extra) > 0 {
+			expectedResult += fmt.Sprintf(", unexpected '%s'", strings.Join(extra, defaultArraySeparator))
+		}
+
+	default:
+		// The separator only applies to the set operations.
+		return compareOp(tCompareOp, flagVal, tCompareValue, flagName)
+	}
+
+	return expectedResult, testResult
+}
+
+// splitElements splits s by sep, ignoring empty elements. A whitespace
+// separator splits on any run of whitespace.
+func splitElements(s, sep string) []string {
+	if strings.TrimSpace(sep) == "" {
+		return strings.Fields(s)
+	}
+
+	var elements []string
+	for _, e := range splitAndRemoveLastSeparator(s, sep) {
+		if e != "" {
+			elements = append(elements, e)
+		}
+	}
+	return elements
+}
+
+// difference returns the elements of a that are not in b, in order.
+func difference(a, b []string) []string {
+	var diff []string
+	for _, e := range a {
+		if indexOf(b, e) < 0 && indexOf(diff, e) < 0 {
+			diff = append(diff, e)
+		}
+	}
+	return diff
+}
+
+// compareOrderOp compares flagVal and tCompareValue by their position in order,
+// which lists the possible values from lowest to highest.
+func compareOrderOp(tCompareOp string, flagVal string, tCompareValue string, order []string, flagName string) (string, bool) {
+	switch tCompareOp {
+	case "eq", "noteq", "gt", "gte", "lt", "lte":
+	default:
+		// The ordering only applies to comparisons.
+		return compareOp(tCompareOp, flagVal, tCompareValue, flagName)
+	}
+
+	expectedResultPattern := ""
+	testResult := false
+	ordering := strings.Join(order, " < ")
+
+	a, b := indexOf(order, flagVal), indexOf(order, tCompareValue)
+	if b < 0 {
+		glog.V(1).Infof("Compare value %q is not one of %v\n", tCompareValue, order)
+		return fmt.Sprintf("Invalid value used for comparison: '%s' is not one of %s", tCompareValue, ordering), false
+	}
+
+	switch tCompareOp {
+	case "eq":
+		expectedResultPattern = "'%s' is equal to %s"
+		testResult = a == b
+
+	case "noteq":
+		expectedResultPattern = "'%s' is not equal to %s"
+		testResult = a != b
+
+	case "gt":
+		expectedResultPattern = "'%s' is greater than %s"
+		testResult = a > b
+
+	case "gte":
+		expectedResultPattern = "'%s' is greater or equal to %s"
+		testResult = a >= b
+
+	case "lt":
+		expectedResultPattern = "'%s' is lower than %s"
+		testResult = a < b
+
+	case "lte":
+		expectedResultPattern = "'%s' is lower or equal to %s"
+		testResult = a <= b
+	}
+
+	if a < 0 && tCompareOp != "noteq" {
+		// Values outside the declared ordering never satisfy a comparison.
+		glog.V(1).Infof("Flag value %q is not one of %v\n", flagVal, order)
+		testResult = false
+	}
+
+	return fmt.Sprintf(expectedResultPattern+" in %s", flagName, tCompareValue, ordering), testResult
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
 func unmarshal(s string, jsonInterface *interface{}) error {
 	data := []byte(s)
 	err := json.Unmarshal(data, jsonInterface)
@@ -375,6 +789,35 @@ func executeJSONPath(path string, jsonInterface interface{}) (string, error) {
 	return jsonpathResult, nil
 }
 
+// executeJSONPathValues returns each value selected by path separately,
+// formatted as executeJSONPath would.
+func executeJSONPathValues(path string, jsonInterface interface{}) ([]string, error) {
+	j := jsonpath.New("jsonpath")
+	j.AllowMissingKeys(true)
+	err := j.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := j.FindResults(jsonInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, r := range results {
+		for _, v := range r {
+			buf := new(bytes.Buffer)
+			err = j.PrintResults(buf, []reflect.Value{v})
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, buf.String())
+		}
+	}
+	return values, nil
+}
+
 func allElementsValid(s, t []string) bool {
 	sourceEmpty := len(s) == 0
 	targetEmpty := len(t) == 0
@@ -432,6 +875,35 @@ func toNumeric(a, b string) (c, d int, err error) {
 	return c, d, nil
 }
 
+// toDuration parses a and b as Go durations, such as 4h0m0s or 5m.
+// A plain 0 is accepted as a zero duration.
+func toDuration(a, b string) (c, d time.Duration, err error) {
+	c, err = time.ParseDuration(strings.TrimSpace(a))
+	if err != nil {
+		return 0, 0, fmt.Errorf("toDuration - error converting %s: %s", a, err)
+	}
+	d, err = time.ParseDuration(strings.TrimSpace(b))
+	if err != nil {
+		return 0, 0, fmt.Errorf("toDuration - error converting %s: %s", b, err)
+	}
+
+	return c, d, nil
+}
+
+// toQuantity parses a and b as Kubernetes quantities, such as 100Mi or 0.5.
+func toQuantity(a, b string) (c, d resource.Quantity, err error) {
+	c, err = resource.ParseQuantity(strings.TrimSpace(a))
+	if err != nil {
+		return c, d, fmt.Errorf("toQuantity - error converting %s: %s", a, err)
+	}
+	d, err = resource.ParseQuantity(strings.TrimSpace(b))
+	if err != nil {
+		return c, d, fmt.Errorf("toQuantity - error converting %s: %s", b, err)
+	}
+
+	return c, d, nil
+}
+
 func (t *testItem) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type buildTest testItem
 
@@ -19,6 +19,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -281,6 +282,30 @@ func TestTestExecute(t *testing.T) {
 			expectedTestResult: "'MAX_BACKLOG' is lower than 30",
 			strEnv:             "MAX_BACKLOG=20",
 		},
+		{
+			check:              controls.Groups[0].Checks[34],
+			str:                "etcd --version=v3.5.9",
+			strConfig:          "",
+			expectedTestResult: "'--version' is a version greater or equal to 3.5.0",
+		},
+		{
+			check:              controls.Groups[0].Checks[35],
+			str:                "2:45 ../kubernetes/kube-apiserver --tls-min-version=VersionTLS13",
+			strConfig:          "",
+			expectedTestResult: "'--tls-min-version' is greater or equal to VersionTLS12 in VersionTLS10 < VersionTLS11 < VersionTLS12 < VersionTLS13",
+		},
+		{
+			check:              controls.Groups[0].Checks[36],
+			str:                "/usr/bin/kubelet --streaming-connection-idle-timeout=4h0m0s",
+			strConfig:          "",
+			expectedTestResult: "'--streaming-connection-idle-timeout' is a duration greater than 0",
+		},
+		{
+			check:              controls.Groups[0].Checks[37],
+			str:                "/usr/bin/kubelet --tls-cipher-suites=TLS_AES_128_GCM_SHA256:TLS_AES_256_GCM_SHA384",
+			strConfig:          "",
+			expectedTestResult: "'--tls-cipher-suites' contains none of 'TLS_RSA_WITH_RC4_128_SHA'",
+		},
 	}
 
 	for _, c := range cases {
@@ -1108,6 +1133,15 @@ func TestCompareOp(t *testing.T) {
 			testResult:            false,
 			flagName:              "flagName",
 		},
+		{
+			label:                 "op=regex, invalid regex",
+			op:                    "regex",
+			flagVal:               "blah",
+			compareValue:          "bl(ah",
+			expectedResultPattern: "Invalid regex used for comparison: 'bl(ah'",
+			testResult:            false,
+			flagName:              "flagName",
+		},
 
 		// Test Op "valid_elements"
 		{
@@ -1222,6 +1256,44 @@ func TestCompareOp(t *testing.T) {
 			testResult:            false,
 			flagName:              "etc/fileExample",
 		},
+
+		// Test Op "semver_gt", "semver_gte", "semver_lt", "semver_lte"
+		{label: "op=semver_gte, both versions equal", op: "semver_gte", flagVal: "3.5.0", compareValue: "3.5.0", expectedResultPattern: "'etcd' is a version greater or equal to 3.5.0", testResult: true, flagName: "etcd"},
+		{label: "op=semver_gte, flagVal with v prefix", op: "semver_gte", flagVal: "v3.5.9", compareValue: "3.5.0", expectedResultPattern: "'etcd' is a version greater or equal to 3.5.0", testResult: true, flagName: "etcd"},
+		{label: "op=semver_gte, flagVal is lower", op: "semver_gte", flagVal: "3.4.27", compareValue: "3.5.0", expectedResultPattern: "'etcd' is a version greater or equal to 3.5.0", testResult: false, flagName: "etcd"},
+		{label: "op=semver_gte, minor versions compared numerically", op: "semver_gte", flagVal: "1.10", compareValue: "1.9", expectedResultPattern: "'etcd' is a version greater or equal to 1.9", testResult: true, flagName: "etcd"},
+		{label: "op=semver_gt, both versions equal", op: "semver_gt", flagVal: "1.28.3", compareValue: "1.28.3", expectedResultPattern: "'kubelet' is a version greater than 1.28.3", testResult: false, flagName: "kubelet"},
+		{label: "op=semver_lt, flagVal with extra metadata", op: "semver_lt", flagVal: "1.27.9-eks-5e0fdde", compareValue: "1.28.0", expectedResultPattern: "'kubelet' is a version lower than 1.28.0", testResult: true, flagName: "kubelet"},
+		{label: "op=semver_lte, flagVal is higher", op: "semver_lte", flagVal: "1.29.0", compareValue: "1.28.0", expectedResultPattern: "'kubelet' is a version lower or equal to 1.28.0", testResult: false, flagName: "kubelet"},
+		{label: "op=semver_gte, flagVal is not a version", op: "semver_gte", flagVal: "latest", compareValue: "3.5.0", expectedResultPattern: "Invalid version(s) used for comparison: 'latest' '3.5.0'", testResult: false, flagName: "etcd"},
+		{label: "op=semver_gte, compareValue is not a version", op: "semver_gte", flagVal: "3.5.0", compareValue: "x", expectedResultPattern: "Invalid version(s) used for comparison: '3.5.0' 'x'", testResult: false, flagName: "etcd"},
+
+		// Test Op "duration_gt", "duration_gte", "duration_lt", "duration_lte"
+		{label: "op=duration_gt, timeout not set to 0", op: "duration_gt", flagVal: "4h0m0s", compareValue: "0", expectedResultPattern: "'--streaming-connection-idle-timeout' is a duration greater than 0", testResult: true, flagName: "--streaming-connection-idle-timeout"},
+		{label: "op=duration_gt, timeout set to 0", op: "duration_gt", flagVal: "0s", compareValue: "0", expectedResultPattern: "'--streaming-connection-idle-timeout' is a duration greater than 0", testResult: false, flagName: "--streaming-connection-idle-timeout"},
+		{label: "op=duration_gte, different units", op: "duration_gte", flagVal: "300s", compareValue: "5m", expectedResultPattern: "'--streaming-connection-idle-timeout' is a duration greater or equal to 5m", testResult: true, flagName: "--streaming-connection-idle-timeout"},
+		{label: "op=duration_lt, higher value", op: "duration_lt", flagVal: "1h", compareValue: "30m", expectedResultPattern: "'--streaming-connection-idle-timeout' is a duration lower than 30m", testResult: false, flagName: "--streaming-connection-idle-timeout"},
+		{label: "op=duration_lte, at least 30 days", op: "duration_lte", flagVal: "720h", compareValue: "720h0m0s", expectedResultPattern: "'--max-age' is a duration lower or equal to 720h0m0s", testResult: true, flagName: "--max-age"},
+		{label: "op=duration_gte, flagVal is not a duration", op: "duration_gte", flagVal: "4 hours", compareValue: "5m", expectedResultPattern: "Invalid duration(s) used for comparison: '4 hours' '5m'", testResult: false, flagName: "--streaming-connection-idle-timeout"},
+
+		// Test Op "quantity_gt", "quantity_gte", "quantity_lt", "quantity_lte"
+		{label: "op=quantity_lte, different suffixes", op: "quantity_lte", flagVal: "100M", compareValue: "100Mi", expectedResultPattern: "'--audit-log-maxsize' is a quantity lower or equal to 100Mi", testResult: true, flagName: "--audit-log-maxsize"},
+		{label: "op=quantity_lte, higher value", op: "quantity_lte", flagVal: "1Gi", compareValue: "100Mi", expectedResultPattern: "'--audit-log-maxsize' is a quantity lower or equal to 100Mi", testResult: false, flagName: "--audit-log-maxsize"},
+		{label: "op=quantity_gte, plain numbers", op: "quantity_gte", flagVal: "100", compareValue: "100", expectedResultPattern: "'--audit-log-maxsize' is a quantity greater or equal to 100", testResult: true, flagName: "--audit-log-maxsize"},
+		{label: "op=quantity_gt, decimal value", op: "quantity_gt", flagVal: "0.5", compareValue: "0", expectedResultPattern: "'--event-qps' is a quantity greater than 0", testResult: true, flagName: "--event-qps"},
+		{label: "op=quantity_lt, milli value", op: "quantity_lt", flagVal: "500m", compareValue: "1", expectedResultPattern: "'--event-qps' is a quantity lower than 1", testResult: true, flagName: "--event-qps"},
+		{label: "op=quantity_gt, compareValue is not a quantity", op: "quantity_gt", flagVal: "5", compareValue: "five", expectedResultPattern: "Invalid quantity(s) used for comparison: '5' 'five'", testResult: false, flagName: "--event-qps"},
+
+		// Test Op "contains_all", "contains_any", "contains_none", "equals_set"
+		{label: "op=contains_all, all present", op: "contains_all", flagVal: "NodeRestriction,EventRateLimit,PodSecurity", compareValue: "NodeRestriction,PodSecurity", expectedResultPattern: "'--enable-admission-plugins' contains all of 'NodeRestriction,PodSecurity'", testResult: true, flagName: "--enable-admission-plugins"},
+		{label: "op=contains_all, some missing", op: "contains_all", flagVal: "NodeRestriction", compareValue: "NodeRestriction,PodSecurity,EventRateLimit", expectedResultPattern: "'--enable-admission-plugins' contains all of 'NodeRestriction,PodSecurity,EventRateLimit', missing 'PodSecurity,EventRateLimit'", testResult: false, flagName: "--enable-admission-plugins"},
+		{label: "op=contains_any, one present", op: "contains_any", flagVal: "Node,RBAC", compareValue: "RBAC,Webhook", expectedResultPattern: "'--authorization-mode' contains any of 'RBAC,Webhook'", testResult: true, flagName: "--authorization-mode"},
+		{label: "op=contains_any, none present", op: "contains_any", flagVal: "AlwaysAllow", compareValue: "RBAC,Webhook", expectedResultPattern: "'--authorization-mode' contains any of 'RBAC,Webhook', none found", testResult: false, flagName: "--authorization-mode"},
+		{label: "op=contains_any, elements are not substrings", op: "contains_any", flagVal: "RBACX", compareValue: "RBAC", expectedResultPattern: "'--authorization-mode' contains any of 'RBAC', none found", testResult: false, flagName: "--authorization-mode"},
+		{label: "op=contains_none, none present", op: "contains_none", flagVal: "Node,RBAC", compareValue: "AlwaysAllow", expectedResultPattern: "'--authorization-mode' contains none of 'AlwaysAllow'", testResult: true, flagName: "--authorization-mode"},
+		{label: "op=contains_none, forbidden present", op: "contains_none", flagVal: "AlwaysAdmit,NodeRestriction,AlwaysPullImages", compareValue: "AlwaysAdmit,AlwaysDeny", expectedResultPattern: "'--enable-admission-plugins' contains none of 'AlwaysAdmit,AlwaysDeny', forbidden 'AlwaysAdmit'", testResult: false, flagName: "--enable-admission-plugins"},
+		{label: "op=equals_set, same elements in a different order", op: "equals_set", flagVal: "RBAC, Node,", compareValue: "Node,RBAC", expectedResultPattern: "'--authorization-mode' is the set 'Node,RBAC'", testResult: true, flagName: "--authorization-mode"},
+		{label: "op=equals_set, missing and unexpected elements", op: "equals_set", flagVal: "Node,AlwaysAllow", compareValue: "Node,RBAC", expectedResultPattern: "'--authorization-mode' is the set 'Node,RBAC', missing 'RBAC', unexpected 'AlwaysAllow'", testResult: false, flagName: "--authorization-mode"},
 	}
 
 	for _, c := range cases {
@@ -1238,6 +1310,246 @@ func TestCompareOp(t *testing.T) {
 	}
 }
 
+func TestCompareSetOp(t *testing.T) {
+	cases := []struct {
+		label                 string
+		op                    string
+		flagVal               string
+		compareValue          string
+		separator             string
+		expectedResultPattern string
+		testResult            bool
+	}{
+		{label: "colon separator", op: "contains_all", flagVal: "TLS_AES_128_GCM_SHA256:TLS_AES_256_GCM_SHA384", compareValue: "TLS_AES_256_GCM_SHA384", separator: ":", expectedResultPattern: "'--tls-cipher-suites' contains all of 'TLS_AES_256_GCM_SHA384'", testResult: true},
+		{label: "whitespace separator", op: "contains_none", flagVal: "TLS_AES_128_GCM_SHA256  TLS_RSA_WITH_RC4_128_SHA\n", compareValue: "TLS_RSA_WITH_RC4_128_SHA,TLS_RSA_WITH_3DES_EDE_CBC_SHA", separator: " ", expectedResultPattern: "'--tls-cipher-suites' contains none of 'TLS_RSA_WITH_RC4_128_SHA,TLS_RSA_WITH_3DES_EDE_CBC_SHA', forbidden 'TLS_RSA_WITH_RC4_128_SHA'", testResult: false},
+		{label: "empty flag value", op: "equals_set", flagVal: "", compareValue: "TLS_AES_128_GCM_SHA256", separator: ":", expectedResultPattern: "'--tls-cipher-suites' is the set 'TLS_AES_128_GCM_SHA256', missing 'TLS_AES_128_GCM_SHA256'", testResult: false},
+		{label: "not a set op", op: "has", flagVal: "TLS_AES_128_GCM_SHA256:TLS_AES_256_GCM_SHA384", compareValue: "AES_256", separator: ":", expectedResultPattern: "'--tls-cipher-suites' has 'AES_256'", testResult: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			expectedResultPattern, testResult := compareSetOp(c.op, c.flagVal, c.compareValue, c.separator, "--tls-cipher-suites")
+			if expectedResultPattern != c.expectedResultPattern {
+				t.Errorf("'expectedResultPattern' did not match - op: %q expected:%q  got:%q", c.op, c.expectedResultPattern, expectedResultPattern)
+			}
+
+			if testResult != c.testResult {
+				t.Errorf("'testResult' did not match - op: %q expected:%t  got:%t", c.op, c.testResult, testResult)
+			}
+		})
+	}
+}
+
+func TestCompareOrderOp(t *testing.T) {
+	order := []string{"VersionTLS10", "VersionTLS11", "VersionTLS12", "VersionTLS13"}
+	ordering := " in VersionTLS10 < VersionTLS11 < VersionTLS12 < VersionTLS13"
+	cases := []struct {
+		label                 string
+		op                    string
+		flagVal               string
+		compareValue          string
+		expectedResultPattern string
+		testResult            bool
+	}{
+		{label: "op=gte, higher value", op: "gte", flagVal: "VersionTLS13", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is greater or equal to VersionTLS12" + ordering, testResult: true},
+		{label: "op=gte, same value", op: "gte", flagVal: "VersionTLS12", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is greater or equal to VersionTLS12" + ordering, testResult: true},
+		{label: "op=gte, lower value", op: "gte", flagVal: "VersionTLS10", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is greater or equal to VersionTLS12" + ordering, testResult: false},
+		{label: "op=gt, same value", op: "gt", flagVal: "VersionTLS12", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is greater than VersionTLS12" + ordering, testResult: false},
+		{label: "op=lt, lower value", op: "lt", flagVal: "VersionTLS11", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is lower than VersionTLS12" + ordering, testResult: true},
+		{label: "op=lte, higher value", op: "lte", flagVal: "VersionTLS13", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is lower or equal to VersionTLS12" + ordering, testResult: false},
+		{label: "op=eq, same value", op: "eq", flagVal: "VersionTLS12", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is equal to VersionTLS12" + ordering, testResult: true},
+		{label: "op=noteq, unknown value", op: "noteq", flagVal: "SSLv3", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is not equal to VersionTLS12" + ordering, testResult: true},
+		{label: "op=lt, unknown value", op: "lt", flagVal: "SSLv3", compareValue: "VersionTLS12", expectedResultPattern: "'--tls-min-version' is lower than VersionTLS12" + ordering, testResult: false},
+		{label: "op=gte, unknown compare value", op: "gte", flagVal: "VersionTLS12", compareValue: "TLS1.2", expectedResultPattern: "Invalid value used for comparison: 'TLS1.2' is not one of VersionTLS10 < VersionTLS11 < VersionTLS12 < VersionTLS13", testResult: false},
+		{label: "op=has, not an ordered op", op: "has", flagVal: "VersionTLS12", compareValue: "TLS12", expectedResultPattern: "'--tls-min-version' has 'TLS12'", testResult: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			expectedResultPattern, testResult := compareOrderOp(c.op, c.flagVal, c.compareValue, order, "--tls-min-version")
+			if expectedResultPattern != c.expectedResultPattern {
+				t.Errorf("'expectedResultPattern' did not match - op: %q expected:%q  got:%q", c.op, c.expectedResultPattern, expectedResultPattern)
+			}
+
+			if testResult != c.testResult {
+				t.Errorf("'testResult' did not match - op: %q expected:%t  got:%t", c.op, c.testResult, testResult)
+			}
+		})
+	}
+}
+
+func TestTestEvaluateValues(t *testing.T) {
+	staticPod := `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-apiserver
+spec:
+  initContainers:
+  - name: setup
+    command: ["sh", "-c", "true"]
+  containers:
+  - name: kube-apiserver
+    command:
+    - kube-apiserver
+    - --profiling=false
+    - --authorization-mode=Node,RBAC
+  - name: audit-sidecar
+    command:
+    - tail
+    - -f
+`
+	kubeconfig := `
+apiVersion: v1
+kind: Config
+contexts:
+- name: admin
+  context:
+    cluster: prod
+    user: admin
+- name: reader
+  context:
+    cluster: prod
+    user: reader
+`
+	multiDoc := `---
+apiVersion: v1
+kind: ConfigMap
+data:
+  profiling: "false"
+---
+apiVersion: v1
+kind: ConfigMap
+data:
+  profiling: "true"
+`
+
+	cases := []struct {
+		label          string
+		item           testItem
+		s              string
+		testResult     bool
+		expectedResult string
+	}{
+		{
+			label:          "container selected by name",
+			item:           testItem{Path: "{.command}", Container: "kube-apiserver", Set: true, Compare: compare{Op: "has", Value: "--profiling=false"}},
+			s:              staticPod,
+			testResult:     true,
+			expectedResult: "'{.command}' has '--profiling=false' for all of 1 values",
+		},
+		{
+			label:          "other container not evaluated",
+			item:           testItem{Path: "{.command}", Container: "audit-sidecar", Set: true, Compare: compare{Op: "has", Value: "--profiling=false"}},
+			s:              staticPod,
+			testResult:     false,
+			expectedResult: "'{.command}' has '--profiling=false' for all of 1 values",
+		},
+		{
+			label:          "init container selected by name",
+			item:           testItem{Path: "{.name}", Container: "setup", Set: true},
+			s:              staticPod,
+			testResult:     true,
+			expectedResult: "'{.name}' is present for all of 1 values",
+		},
+		{
+			label:          "missing container",
+			item:           testItem{Path: "{.command}", Container: "etcd", Set: true},
+			s:              staticPod,
+			testResult:     false,
+			expectedResult: "'{.command}' is present for all of 0 values",
+		},
+		{
+			label:          "all containers",
+			item:           testItem{Path: "{.spec.containers[*].name}", Match: matchAll, Set: true, Compare: compare{Op: "noteq", Value: "audit-sidecar"}},
+			s:              staticPod,
+			testResult:     false,
+			expectedResult: "'{.spec.containers[*].name}' is not equal to 'audit-sidecar' for all of 2 values",
+		},
+		{
+			label:          "all contexts",
+			item:           testItem{Path: "{.contexts[*].context.cluster}", Match: matchAll, Set: true, Compare: compare{Op: "eq", Value: "prod"}},
+			s:              kubeconfig,
+			testResult:     true,
+			expectedResult: "'{.contexts[*].context.cluster}' is equal to 'prod' for all of 2 values",
+		},
+		{
+			label:          "any context",
+			item:           testItem{Path: "{.contexts[*].context.user}", Match: matchAny, Set: true, Compare: compare{Op: "eq", Value: "reader"}},
+			s:              kubeconfig,
+			testResult:     true,
+			expectedResult: "'{.contexts[*].context.user}' is equal to 'reader' for any of 2 values",
+		},
+		{
+			label:          "all documents",
+			item:           testItem{Path: "{.data.profiling}", Match: matchAll, Set: true, Compare: compare{Op: "eq", Value: "false"}},
+			s:              multiDoc,
+			testResult:     false,
+			expectedResult: "'{.data.profiling}' is equal to 'false' for all of 2 values",
+		},
+		{
+			label:          "any document",
+			item:           testItem{Path: "{.data.profiling}", Match: matchAny, Set: true, Compare: compare{Op: "eq", Value: "false"}},
+			s:              multiDoc,
+			testResult:     true,
+			expectedResult: "'{.data.profiling}' is equal to 'false' for any of 2 values",
+		},
+		{
+			label:          "JSON document",
+			item:           testItem{Path: "{.spec.containers[*].image}", Match: matchAll, Set: true, Compare: compare{Op: "has", Value: "registry.k8s.io"}},
+			s:              `{"spec": {"containers": [{"name": "a", "image": "registry.k8s.io/a"}, {"name": "b", "image": "registry.k8s.io/b"}]}}`,
+			testResult:     true,
+			expectedResult: "'{.spec.containers[*].image}' has 'registry.k8s.io' for all of 2 values",
+		},
+		{
+			label:          "path not set in any document",
+			item:           testItem{Path: "{.data.missing}", Match: matchAny, Set: false},
+			s:              multiDoc,
+			testResult:     true,
+			expectedResult: "'{.data.missing}' is not present for any of 0 values",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			c.item.auditUsed = AuditConfig
+			res := c.item.execute(c.s)
+			if res.testResult != c.testResult {
+				t.Errorf("expected:%t, got:%t", c.testResult, res.testResult)
+			}
+			if res.ExpectedResult != c.expectedResult {
+				t.Errorf("\nexpected:%v, \ngot:     %v", c.expectedResult, res.ExpectedResult)
+			}
+		})
+	}
+}
+
+func TestFlagTestItemFindValueEscapesFlag(t *testing.T) {
+	cases := []struct {
+		flag  string
+		s     string
+		match bool
+		value string
+	}{
+		{flag: "authentication.anonymous.enabled", s: "authentication.anonymous.enabled: false", match: true, value: "false"},
+		{flag: "authentication.anonymous.enabled", s: "authenticationXanonymous.enabled: true authentication.anonymous.enabled=false", match: true, value: "false"},
+		{flag: "--feature-gates(x)", s: "--feature-gates(x)=RotateKubeletServerCertificate=true", match: true, value: "RotateKubeletServerCertificate=true"},
+		{flag: "--flag[0]", s: "--flag0=true", match: false, value: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.flag, func(t *testing.T) {
+			match, value, err := flagTestItem{Flag: c.flag}.findValue(c.s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if match != c.match || value != c.value {
+				t.Errorf("expected %t %q, got %t %q", c.match, c.value, match, value)
+			}
+		})
+	}
+}
+
 func TestToNumeric(t *testing.T) {
 	cases := []struct {
 		firstValue     string
@@ -1275,6 +1587,58 @@ func TestToNumeric(t *testing.T) {
 	}
 }
 
+func TestToDuration(t *testing.T) {
+	cases := []struct {
+		firstValue     string
+		secondValue    string
+		expectedToFail bool
+	}{
+		{firstValue: "a", secondValue: "5m", expectedToFail: true},
+		{firstValue: "5m", secondValue: "10", expectedToFail: true},
+		{firstValue: "5m", secondValue: "1h", expectedToFail: false},
+		{firstValue: " 300s", secondValue: "60m0s ", expectedToFail: false},
+	}
+
+	for id, c := range cases {
+		t.Run(fmt.Sprintf("%d", id), func(t *testing.T) {
+			f, s, err := toDuration(c.firstValue, c.secondValue)
+			if c.expectedToFail && err == nil {
+				t.Errorf("Expected error while converting %s and %s", c.firstValue, c.secondValue)
+			}
+
+			if !c.expectedToFail && (f != 5*time.Minute || s != time.Hour) {
+				t.Errorf("Expected to return %s,%s - got %s,%s", 5*time.Minute, time.Hour, f, s)
+			}
+		})
+	}
+}
+
+func TestToQuantity(t *testing.T) {
+	cases := []struct {
+		firstValue     string
+		secondValue    string
+		expectedToFail bool
+	}{
+		{firstValue: "a", secondValue: "1Mi", expectedToFail: true},
+		{firstValue: "1Mi", secondValue: "1MB", expectedToFail: true},
+		{firstValue: "1Mi", secondValue: "1M", expectedToFail: false},
+		{firstValue: "1048576", secondValue: "1000k", expectedToFail: false},
+	}
+
+	for id, c := range cases {
+		t.Run(fmt.Sprintf("%d", id), func(t *testing.T) {
+			f, s, err := toQuantity(c.firstValue, c.secondValue)
+			if c.expectedToFail && err == nil {
+				t.Errorf("Expected error while converting %s and %s", c.firstValue, c.secondValue)
+			}
+
+			if !c.expectedToFail && (f.Value() != 1048576 || s.Value() != 1000000) {
+				t.Errorf("Expected to return %d,%d - got %d,%d", 1048576, 1000000, f.Value(), s.Value())
+			}
+		})
+	}
+}
+
 func TestExecuteJSONPathOnEncryptionConfig(t *testing.T) {
 	type Resources struct {
 		Resources []string                 `json:"resources"`
@@ -0,0 +1,126 @@
+// Copyright © 2017 Aqua Security Software Ltd. <info@aquasec.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// Trace records how a check was evaluated during its last run.
+type Trace struct {
+	CheckID    string       `json:"test_number"`
+	Start      time.Time    `json:"start"`
+	End        time.Time    `json:"end"`
+	DurationMs float64      `json:"duration_ms"`
+	Audits     []AuditTrace `json:"audits,omitempty"`
+	Tests      []TestTrace  `json:"tests,omitempty"`
+	BinOp      string       `json:"bin_op,omitempty"`
+	// BinOpResult is the result of the tests combined with BinOp.
+	BinOpResult bool   `json:"bin_op_result"`
+	State       State  `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// AuditTrace records a single audit command run for a check.
+type AuditTrace struct {
+	Source     AuditUsed `json:"source"`
+	Command    string    `json:"command"`
+	Output     string    `json:"output"`
+	ExitStatus int       `json:"exit_status"`
+	DurationMs float64   `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// TestTrace records how a single test item was evaluated.
+type TestTrace struct {
+	Flag           string        `json:"flag,omitempty"`
+	Path           string        `json:"path,omitempty"`
+	Env            string        `json:"env,omitempty"`
+	Match          string        `json:"match,omitempty"`
+	Container      string        `json:"container,omitempty"`
+	Set            bool          `json:"set"`
+	Op             string        `json:"op,omitempty"`
+	CompareValue   string        `json:"compare_value,omitempty"`
+	Attempts       []TestAttempt `json:"attempts"`
+	AuditUsed      AuditUsed     `json:"audit_used"`
+	Result         bool          `json:"result"`
+	ExpectedResult string        `json:"expected_result"`
+}
+
+// TestAttempt records the evaluation of a test item against the output of one audit source.
+type TestAttempt struct {
+	Source         AuditUsed `json:"source"`
+	Found          bool      `json:"found"`
+	Value          string    `json:"value"`
+	Result         bool      `json:"result"`
+	ExpectedResult string    `json:"expected_result"`
+}
+
+func newTrace(c *Check) *Trace {
+	return &Trace{CheckID: c.ID, Start: time.Now()}
+}
+
+func (tr *Trace) finish(c *Check) {
+	if tr == nil {
+		return
+	}
+	tr.End = time.Now()
+	tr.DurationMs = durationMs(tr.End.Sub(tr.Start))
+	tr.State = c.State
+	tr.Reason = c.Reason
+}
+
+func (tr *Trace) addAudit(source AuditUsed, command, output string, err error, d time.Duration) {
+	if tr == nil {
+		return
+	}
+	at := AuditTrace{
+		Source:     source,
+		Command:    command,
+		Output:     output,
+		ExitStatus: exitStatus(err),
+		DurationMs: durationMs(d),
+	}
+	if err != nil {
+		at.Error = err.Error()
+	}
+	tr.Audits = append(tr.Audits, at)
+}
+
+func (tr *Trace) addTest(tt TestTrace) {
+	if tr == nil {
+		return
+	}
+	tr.Tests = append(tr.Tests, tt)
+}
+
+// exitStatus returns the exit status of the process that returned err,
+// or -1 if it could not be run.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/golang/glog"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultAliasesFile = "aliases.yaml"
+
+// checkAliasesFile is the format of the file mapping canonical check IDs to their IDs in each benchmark.
+type checkAliasesFile struct {
+	Aliases check.AliasMap `yaml:"aliases"`
+}
+
+// checkAliases caches the aliases loaded by getCheckAliases.
+var checkAliases check.AliasMap
+
+// getCheckAliases returns the check aliases of the file given with --aliases, or of aliases.yaml
+// in the config directory when it exists.
+func getCheckAliases() (check.AliasMap, error) {
+	if checkAliases != nil {
+		return checkAliases, nil
+	}
+
+	path := aliasesFile
+	if path == "" {
+		path = filepath.Join(cfgDir, defaultAliasesFile)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			glog.V(2).Infof("No check aliases file %s", path)
+			checkAliases = check.AliasMap{}
+			return checkAliases, nil
+		}
+	}
+
+	aliases, err := loadCheckAliases(path)
+	if err != nil {
+		return nil, err
+	}
+	checkAliases = aliases
+	recordConfigFile(path)
+	return checkAliases, nil
+}
+
+// loadCheckAliases reads a check aliases file and validates it.
+func loadCheckAliases(path string) (check.AliasMap, error) {
+	in, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading check aliases file %s: %v", path, err)
+	}
+
+	var f checkAliasesFile
+	if err := yaml.Unmarshal(in, &f); err != nil {
+		return nil, fmt.Errorf("error parsing check aliases file %s: %v", path, err)
+	}
+
+	// A check of a benchmark can't have two canonical IDs.
+	seen := make(map[string]string)
+	for canonical, ids := range f.Aliases {
+		if canonical == "" || strings.Contains(canonical, ":") {
+			return nil, fmt.Errorf("check aliases file %s: invalid canonical ID %q", path, canonical)
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("check aliases file %s: canonical ID %q maps no check", path, canonical)
+		}
+		for benchmark, id := range ids {
+			qid := check.QualifiedID(benchmark, id)
+			if other, ok := seen[qid]; ok {
+				return nil, fmt.Errorf("check aliases file %s: check %s has two canonical IDs, %q and %q", path, qid, other, canonical)
+			}
+			seen[qid] = canonical
+		}
+	}
+
+	if f.Aliases == nil {
+		f.Aliases = check.AliasMap{}
+	}
+	return f.Aliases, nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCheckAliases(t *testing.T) {
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name    string
+		content string
+		exp     check.AliasMap
+		expErr  string
+	}{
+		{
+			name: "valid",
+			content: `aliases:
+  apiserver-profiling:
+    cis-1.8: "1.2.16"
+    cis-1.9: "1.2.15"
+`,
+			exp: check.AliasMap{"apiserver-profiling": {"cis-1.8": "1.2.16", "cis-1.9": "1.2.15"}},
+		},
+		{
+			name:    "empty",
+			content: "",
+			exp:     check.AliasMap{},
+		},
+		{
+			name:    "invalid yaml",
+			content: "aliases: [",
+			expErr:  "error parsing check aliases file",
+		},
+		{
+			name: "qualified canonical ID",
+			content: `aliases:
+  cis-1.8:1.2.16:
+    cis-1.9: "1.2.15"
+`,
+			expErr: `invalid canonical ID "cis-1.8:1.2.16"`,
+		},
+		{
+			name: "no check",
+			content: `aliases:
+  apiserver-profiling: {}
+`,
+			expErr: `canonical ID "apiserver-profiling" maps no check`,
+		},
+		{
+			name: "two canonical IDs",
+			content: `aliases:
+  apiserver-profiling:
+    cis-1.9: "1.2.15"
+  apiserver-audit-log-path:
+    cis-1.9: "1.2.15"
+`,
+			expErr: "check cis-1.9:1.2.15 has two canonical IDs",
+		},
+	}
+	for i, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(dir, c.name+".yaml")
+			if err := os.WriteFile(path, []byte(c.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			aliases, err := loadCheckAliases(path)
+			if c.expErr != "" {
+				assert.ErrorContains(t, err, c.expErr, "case %d", i)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.exp, aliases)
+		})
+	}
+
+	_, err := loadCheckAliases(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "error reading check aliases file")
+}
+
+func TestGetCheckAliases(t *testing.T) {
+	oldCfgDir, oldAliasesFile := cfgDir, aliasesFile
+	defer func() {
+		cfgDir, aliasesFile, checkAliases = oldCfgDir, oldAliasesFile, nil
+	}()
+
+	cfgDir, aliasesFile, checkAliases = t.TempDir(), "", nil
+	aliases, err := getCheckAliases()
+	assert.NoError(t, err)
+	assert.Empty(t, aliases)
+
+	cfgDir, aliasesFile, checkAliases = t.TempDir(), "/nonexistent/aliases.yaml", nil
+	_, err = getCheckAliases()
+	assert.Error(t, err)
+
+	cfgDir, aliasesFile, checkAliases = "../cfg", "", nil
+	aliases, err = getCheckAliases()
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.15", aliases["apiserver-profiling"]["cis-1.9"])
+}
+
+// TestCheckAliasesFile verifies that every check mapped in cfg/aliases.yaml exists, and that
+// the checks sharing a canonical ID are the same recommendation.
+func TestCheckAliasesFile(t *testing.T) {
+	aliases, err := loadCheckAliases("../cfg/" + defaultAliasesFile)
+	assert.NoError(t, err)
+
+	texts := make(map[string]map[string]string)
+	for canonical, ids := range aliases {
+		var first string
+		for benchmark, id := range ids {
+			if texts[benchmark] == nil {
+				checks, err := loadBenchmarkChecks("../cfg", benchmark)
+				if !assert.NoError(t, err, "canonical ID %s", canonical) {
+					continue
+				}
+				texts[benchmark] = make(map[string]string)
+				for _, c := range checks {
+					texts[benchmark][c.ID] = c.Text
+				}
+			}
+
+			text, ok := texts[benchmark][id]
+			if !assert.True(t, ok, "%s: check %s not found in %s", canonical, id, benchmark) {
+				continue
+			}
+			if first == "" {
+				first = text
+				continue
+			}
+			assert.GreaterOrEqual(t, textSimilarity(first, text), 0.8, "%s: %s %s is %q, expected %q", canonical, benchmark, id, text, first)
+		}
+	}
+}
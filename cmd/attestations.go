@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// attestationDateLayout is the layout of the date of an attestation.
+const attestationDateLayout = "2006-01-02"
+
+// checkAttestationsFile is the format of the file recording the outcome of manual checks reviewed by people.
+type checkAttestationsFile struct {
+	Benchmark    string              `yaml:"benchmark"`
+	Attestations []check.Attestation `yaml:"attestations"`
+}
+
+// checkAttestations caches the attestations loaded by getAttestations.
+var checkAttestations []check.Attestation
+
+func init() {
+	RootCmd.AddCommand(attestCmd)
+}
+
+var attestCmd = &cobra.Command{
+	Use:   "attest [benchmark]",
+	Short: "Write an attestations template for the manual checks of a benchmark",
+	Long: `Write an attestations template for the manual checks of a benchmark, to record the outcome of
+their review and pass it to kube-bench run with --attestations. The benchmark is the one given, or the
+one selected by --benchmark or --version or for the detected Kubernetes version.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var bv string
+		if len(args) > 0 {
+			bv = args[0]
+		} else {
+			var err error
+			bv, err = resolveBenchmarkVersion(viper.GetViper())
+			if err != nil {
+				exitWithError(fmt.Errorf("unable to get benchmark version. error: %v", err))
+			}
+		}
+
+		checks, err := loadBenchmarkChecks(cfgDir, bv)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		w := io.Writer(os.Stdout)
+		if outputFile != "" {
+			f, err := os.Create(outputFile)
+			if err != nil {
+				exitWithError(fmt.Errorf("error creating attestations file %s: %v", outputFile, err))
+			}
+			defer f.Close()
+			w = f
+		}
+		writeAttestationsTemplate(w, bv, checks)
+	},
+}
+
+// getAttestations returns the attestations of the file given with --attestations, if any.
+func getAttestations() ([]check.Attestation, error) {
+	if attestationsFile == "" || checkAttestations != nil {
+		return checkAttestations, nil
+	}
+
+	aliases, err := getCheckAliases()
+	if err != nil {
+		return nil, err
+	}
+	attestations, err := loadAttestations(attestationsFile, aliases, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	checkAttestations = attestations
+	recordConfigFile(attestationsFile)
+	return checkAttestations, nil
+}
+
+// loadAttestations reads an attestations file and validates it, reporting every invalid attestation.
+// Attestations without an outcome haven't been reviewed yet, and are left out. When the file is for
+// a benchmark, the check IDs it lists that aren't canonical IDs in aliases are qualified with the
+// benchmark, so that they only match the checks of other benchmarks through their aliases.
+func loadAttestations(path string, aliases check.AliasMap, now time.Time) ([]check.Attestation, error) {
+	in, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attestations file %s: %v", path, err)
+	}
+
+	var f checkAttestationsFile
+	if err := yaml.UnmarshalStrict(in, &f); err != nil {
+		return nil, fmt.Errorf("error parsing attestations file %s: %v", path, err)
+	}
+
+	attestations := []check.Attestation{}
+	seen := make(map[string]bool)
+	var errs []error
+	for i, a := range f.Attestations {
+		if a.Outcome == "" {
+			continue
+		}
+		if err := validateAttestation(a, now); err != nil {
+			errs = append(errs, fmt.Errorf("attestation %d (%s): %v", i+1, a.ID, err))
+			continue
+		}
+		if f.Benchmark != "" && !strings.Contains(a.ID, ":") && aliases[a.ID] == nil {
+			a.ID = check.QualifiedID(f.Benchmark, a.ID)
+		}
+		if seen[a.ID] {
+			errs = append(errs, fmt.Errorf("attestation %d (%s): check attested twice", i+1, a.ID))
+			continue
+		}
+		seen[a.ID] = true
+		attestations = append(attestations, a)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid attestations file %s:\n%v", path, errors.Join(errs...))
+	}
+	return attestations, nil
+}
+
+// validateAttestation returns the problems of an attestation, all of whose fields are required.
+func validateAttestation(a check.Attestation, now time.Time) error {
+	var errs []error
+	if a.ID == "" {
+		errs = append(errs, errors.New("id is required"))
+	}
+	if a.Outcome != check.AttestedPass && a.Outcome != check.AttestedFail {
+		errs = append(errs, fmt.Errorf("outcome %q isn't %s or %s", a.Outcome, check.AttestedPass, check.AttestedFail))
+	}
+	if strings.TrimSpace(a.Evidence) == "" {
+		errs = append(errs, errors.New("evidence is required"))
+	}
+	if strings.TrimSpace(a.Reviewer) == "" {
+		errs = append(errs, errors.New("reviewer is required"))
+	}
+	if date, err := time.Parse(attestationDateLayout, a.Date); err != nil {
+		errs = append(errs, fmt.Errorf("date %q isn't formatted as YYYY-MM-DD", a.Date))
+	} else if date.After(now) {
+		errs = append(errs, fmt.Errorf("date %s is in the future", a.Date))
+	}
+	return errors.Join(errs...)
+}
+
+// writeAttestationsTemplate writes an attestations file for the manual checks of benchmark, without outcomes.
+func writeAttestationsTemplate(w io.Writer, benchmark string, checks []benchmarkCheck) {
+	fmt.Fprintf(w, "# Attestations of the manual checks of %s, for kube-bench run --attestations.\n", benchmark)
+	fmt.Fprintln(w, "# Set the outcome of each reviewed check to pass or fail, with the evidence, the reviewer")
+	fmt.Fprintln(w, "# and the date of the review (YYYY-MM-DD). Checks without an outcome are reported as manual.")
+	fmt.Fprintf(w, "benchmark: %s\n", benchmark)
+	fmt.Fprintln(w, "attestations:")
+	for _, c := range checks {
+		if c.Type != check.MANUAL {
+			continue
+		}
+		fmt.Fprintf(w, "  # %s %s: %s\n", c.Target, c.ID, strings.Join(strings.Fields(c.Text), " "))
+		fmt.Fprintf(w, "  - id: %q\n", c.ID)
+		fmt.Fprintln(w, `    outcome: ""`)
+		fmt.Fprintln(w, `    evidence: ""`)
+		fmt.Fprintln(w, `    reviewer: ""`)
+		fmt.Fprintln(w, `    date: ""`)
+	}
+}
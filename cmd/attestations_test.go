@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestLoadAttestations(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	aliases := check.AliasMap{"namespace-administrative-boundaries": {"cis-1.10": "5.7.1", "cis-1.12": "5.6.1"}}
+
+	testCases := []struct {
+		name    string
+		content string
+		exp     []check.Attestation
+		expErrs []string
+	}{
+		{
+			name: "for any benchmark",
+			content: `attestations:
+- id: 5.1.7
+  outcome: pass
+  evidence: no binding to system:masters
+  reviewer: alice
+  date: 2026-10-01
+- id: "5.1.8"
+  outcome: ""
+`,
+			exp: []check.Attestation{{ID: "5.1.7", Outcome: "pass", Evidence: "no binding to system:masters", Reviewer: "alice", Date: "2026-10-01"}},
+		},
+		{
+			name: "for a benchmark",
+			content: `benchmark: cis-1.12
+attestations:
+- {id: "5.1.7", outcome: fail, evidence: OPS-42, reviewer: bob, date: 2026-10-19}
+- {id: namespace-administrative-boundaries, outcome: pass, evidence: OPS-43, reviewer: bob, date: 2026-10-19}
+- {id: "cis-1.11:5.1.8", outcome: pass, evidence: OPS-44, reviewer: bob, date: 2026-10-19}
+`,
+			exp: []check.Attestation{
+				{ID: "cis-1.12:5.1.7", Outcome: "fail", Evidence: "OPS-42", Reviewer: "bob", Date: "2026-10-19"},
+				{ID: "namespace-administrative-boundaries", Outcome: "pass", Evidence: "OPS-43", Reviewer: "bob", Date: "2026-10-19"},
+				{ID: "cis-1.11:5.1.8", Outcome: "pass", Evidence: "OPS-44", Reviewer: "bob", Date: "2026-10-19"},
+			},
+		},
+		{
+			name: "invalid attestations",
+			content: `attestations:
+- {outcome: passed, evidence: " ", date: 10/01/2026}
+- {id: "5.1.7", outcome: pass, evidence: OPS-42, reviewer: bob, date: 2026-10-20}
+- {id: "5.1.8", outcome: pass, evidence: OPS-42, reviewer: bob, date: 2026-10-01}
+- {id: "5.1.8", outcome: fail, evidence: OPS-43, reviewer: bob, date: 2026-10-02}
+`,
+			expErrs: []string{
+				`attestation 1 (): id is required`,
+				`outcome "passed" isn't pass or fail`,
+				"evidence is required",
+				"reviewer is required",
+				`date "10/01/2026" isn't formatted as YYYY-MM-DD`,
+				"attestation 2 (5.1.7): date 2026-10-20 is in the future",
+				"attestation 4 (5.1.8): check attested twice",
+			},
+		},
+		{
+			name:    "unknown field",
+			content: "attestations:\n- {id: 5.1.7, outcome: pass, comment: ok}\n",
+			expErrs: []string{"error parsing attestations file", "field comment not found"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "attestations.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(c.content), 0o600))
+
+			attestations, err := loadAttestations(path, aliases, now)
+			if len(c.expErrs) > 0 {
+				for _, e := range c.expErrs {
+					assert.ErrorContains(t, err, e)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.exp, attestations)
+		})
+	}
+
+	_, err := loadAttestations(filepath.Join(t.TempDir(), "missing.yaml"), aliases, now)
+	assert.ErrorContains(t, err, "error reading attestations file")
+}
+
+func TestWriteAttestationsTemplate(t *testing.T) {
+	checks, err := loadBenchmarkChecks("../cfg", "cis-1.12")
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	writeAttestationsTemplate(&out, "cis-1.12", checks)
+	assert.Contains(t, out.String(), "benchmark: cis-1.12\n")
+	assert.Contains(t, out.String(), `  # policies 5.1.7: Avoid use of system:masters group (Manual)
+  - id: "5.1.7"
+    outcome: ""
+`)
+
+	var f checkAttestationsFile
+	assert.NoError(t, yaml.UnmarshalStrict(out.Bytes(), &f))
+	assert.Equal(t, "cis-1.12", f.Benchmark)
+	manual := 0
+	for _, c := range checks {
+		if c.Type == check.MANUAL {
+			manual++
+		}
+	}
+	assert.Len(t, f.Attestations, manual)
+
+	// A template without outcomes attests nothing.
+	path := filepath.Join(t.TempDir(), "attestations.yaml")
+	assert.NoError(t, os.WriteFile(path, out.Bytes(), 0o600))
+	attestations, err := loadAttestations(path, nil, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, attestations)
+}
@@ -0,0 +1,423 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// defaultSimilarity is the text similarity above which two checks are considered the same.
+const defaultSimilarity = 0.8
+
+var compareSimilarity float64
+
+func init() {
+	benchmarksCmd.AddCommand(benchmarksListCmd)
+	benchmarksCmd.AddCommand(benchmarksShowCmd)
+	benchmarksCmd.AddCommand(benchmarksCompareCmd)
+	benchmarksCompareCmd.Flags().Float64Var(&compareSimilarity, "similarity", defaultSimilarity,
+		"Text similarity, from 0 to 1, above which checks with different IDs are reported as renumbered")
+	RootCmd.AddCommand(benchmarksCmd)
+}
+
+// benchmarksCmd represents the benchmarks command
+var benchmarksCmd = &cobra.Command{
+	Use:   "benchmarks",
+	Short: "List, show and compare the benchmarks in the config directory",
+	Long:  `List, show and compare the benchmarks in the config directory.`,
+}
+
+var benchmarksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every benchmark with the versions and platforms mapped to it and its targets",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		benchmarks, err := listBenchmarks(cfgDir, viper.GetViper())
+		if err != nil {
+			exitWithError(err)
+		}
+		printBenchmarkList(os.Stdout, benchmarks)
+	},
+}
+
+var benchmarksShowCmd = &cobra.Command{
+	Use:   "show <benchmark>",
+	Short: "List all checks of a benchmark",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checks, err := loadBenchmarkChecks(cfgDir, args[0])
+		if err != nil {
+			exitWithError(err)
+		}
+		if jsonFmt {
+			printJSON(checks)
+			return
+		}
+		printBenchmarkChecks(os.Stdout, checks)
+	},
+}
+
+var benchmarksCompareCmd = &cobra.Command{
+	Use:   "compare <from benchmark> <to benchmark>",
+	Short: "Show the checks added, removed and renumbered between two benchmarks",
+	Long: `Show the checks added, removed and renumbered between two benchmarks. Checks are matched
+by the similarity of their text, so that waivers, skips and dashboards can be moved to the new check IDs.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := loadBenchmarkChecks(cfgDir, args[0])
+		if err != nil {
+			exitWithError(err)
+		}
+		to, err := loadBenchmarkChecks(cfgDir, args[1])
+		if err != nil {
+			exitWithError(err)
+		}
+		comparison := compareBenchmarks(from, to, compareSimilarity)
+		if jsonFmt {
+			printJSON(comparison)
+			return
+		}
+		printBenchmarkComparison(os.Stdout, args[0], args[1], comparison)
+	},
+}
+
+// benchmarkInfo describes a benchmark found in the config directory.
+type benchmarkInfo struct {
+	Name         string   `json:"name"`
+	KubeVersions []string `json:"kube_versions,omitempty"`
+	Platforms    []string `json:"platforms,omitempty"`
+	Targets      []string `json:"targets,omitempty"`
+}
+
+// benchmarkCheck is a check of a benchmark, as written in its controls files.
+type benchmarkCheck struct {
+	ID     string         `json:"id"`
+	Text   string         `json:"text"`
+	Target check.NodeType `json:"target"`
+	Group  string         `json:"group"`
+	Scored bool           `json:"scored"`
+	Type   string         `json:"type,omitempty"`
+}
+
+// checkMapping maps a check of one benchmark to the most similar check of another.
+type checkMapping struct {
+	From       benchmarkCheck `json:"from"`
+	To         benchmarkCheck `json:"to"`
+	Similarity float64        `json:"similarity"`
+}
+
+// benchmarkComparison is the difference between the checks of two benchmarks.
+type benchmarkComparison struct {
+	Added      []benchmarkCheck `json:"added"`
+	Removed    []benchmarkCheck `json:"removed"`
+	Renumbered []checkMapping   `json:"renumbered"`
+	// Reworded are checks that kept their ID but whose text changed.
+	Reworded  []checkMapping `json:"reworded"`
+	Unchanged int            `json:"unchanged"`
+}
+
+// listBenchmarks returns the benchmarks in dir, with the Kubernetes versions and platforms
+// that select them and their targets, as configured in v.
+func listBenchmarks(dir string, v *viper.Viper) ([]benchmarkInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory %s: %v", dir, err)
+	}
+
+	versions := make(map[string][]string)
+	for kv, bv := range v.GetStringMapString("version_mapping") {
+		versions[bv] = append(versions[bv], kv)
+	}
+	platforms := make(map[string][]string)
+	if configs, err := loadPlatformConfigs(v); err == nil {
+		for _, p := range configs {
+			for _, bv := range p.Benchmarks {
+				platforms[bv] = appendUnique(platforms[bv], p.Name)
+			}
+			if p.DefaultBenchmark != "" {
+				platforms[p.DefaultBenchmark] = appendUnique(platforms[p.DefaultBenchmark], p.Name)
+			}
+		}
+	}
+	targets := v.GetStringMapStringSlice("target_mapping")
+
+	var benchmarks []benchmarkInfo
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		b := benchmarkInfo{
+			Name:         e.Name(),
+			KubeVersions: versions[e.Name()],
+			Platforms:    platforms[e.Name()],
+			Targets:      targets[e.Name()],
+		}
+		sortVersions(b.KubeVersions)
+		benchmarks = append(benchmarks, b)
+	}
+	return benchmarks, nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, l := range list {
+		if l == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// sortVersions sorts Kubernetes versions numerically, before any other version_mapping keys.
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		a, aErr := version.ParseGeneric(versions[i])
+		b, bErr := version.ParseGeneric(versions[j])
+		if aErr != nil || bErr != nil {
+			if (aErr == nil) != (bErr == nil) {
+				return aErr == nil
+			}
+			return versions[i] < versions[j]
+		}
+		return a.LessThan(b)
+	})
+}
+
+func printBenchmarkList(w io.Writer, benchmarks []benchmarkInfo) {
+	for _, b := range benchmarks {
+		fmt.Fprintln(w, b.Name)
+		if len(b.KubeVersions) > 0 {
+			fmt.Fprintf(w, "\t versions: %s\n", strings.Join(b.KubeVersions, ", "))
+		}
+		if len(b.Platforms) > 0 {
+			fmt.Fprintf(w, "\t platforms: %s\n", strings.Join(b.Platforms, ", "))
+		}
+		if len(b.Targets) > 0 {
+			fmt.Fprintf(w, "\t targets: %s\n", strings.Join(b.Targets, ", "))
+		}
+	}
+}
+
+// loadBenchmarkChecks reads the checks of all controls files of benchmark, ordered by target.
+func loadBenchmarkChecks(dir, benchmark string) ([]benchmarkCheck, error) {
+	path := filepath.Join(dir, benchmark)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("benchmark %s not found in %s", benchmark, dir)
+	}
+	files, err := getYamlFilesFromDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark %s: %v", benchmark, err)
+	}
+	sort.Strings(files)
+
+	var checks []benchmarkCheck
+	for _, file := range files {
+		in, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error opening %s test file: %v", file, err)
+		}
+		var controls check.Controls
+		if err := yaml.Unmarshal(in, &controls); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		for _, g := range controls.Groups {
+			for _, c := range g.Checks {
+				checks = append(checks, benchmarkCheck{
+					ID:     c.ID,
+					Text:   c.Text,
+					Target: controls.Type,
+					Group:  g.ID,
+					Scored: c.Scored,
+					Type:   c.Type,
+				})
+			}
+		}
+	}
+	return checks, nil
+}
+
+func printBenchmarkChecks(w io.Writer, checks []benchmarkCheck) {
+	target := check.NodeType("")
+	for _, c := range checks {
+		if c.Target != target {
+			target = c.Target
+			fmt.Fprintf(w, "== %s ==\n", target)
+		}
+		kind := "scored"
+		if !c.Scored {
+			kind = "not scored"
+		}
+		if c.Type != "" {
+			kind += ", " + c.Type
+		}
+		fmt.Fprintf(w, "%s [%s] %s\n", c.ID, kind, c.Text)
+	}
+}
+
+// sameIDBonus favours keeping the ID of a check when several checks have similar texts.
+const sameIDBonus = 0.05
+
+// compareBenchmarks matches the checks of from with the checks of to, most similar pairs first,
+// considering pairs whose text similarity is at least minSimilarity. Matched checks with
+// different IDs are renumbered, and unmatched checks are removed or added.
+func compareBenchmarks(from, to []benchmarkCheck, minSimilarity float64) benchmarkComparison {
+	type candidate struct {
+		checkMapping
+		from, to int
+		score    float64
+	}
+	var candidates []candidate
+	for i, f := range from {
+		for j, t := range to {
+			sim := textSimilarity(f.Text, t.Text)
+			if sim < minSimilarity {
+				continue
+			}
+			score := sim
+			if f.ID == t.ID {
+				score += sameIDBonus
+			}
+			candidates = append(candidates, candidate{checkMapping{From: f, To: t, Similarity: sim}, i, j, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	var result benchmarkComparison
+	fromMatched := make([]bool, len(from))
+	toMatched := make([]bool, len(to))
+	for _, c := range candidates {
+		if fromMatched[c.from] || toMatched[c.to] {
+			continue
+		}
+		fromMatched[c.from], toMatched[c.to] = true, true
+		switch {
+		case c.From.ID != c.To.ID:
+			result.Renumbered = append(result.Renumbered, c.checkMapping)
+		case c.Similarity < 1:
+			result.Reworded = append(result.Reworded, c.checkMapping)
+		default:
+			result.Unchanged++
+		}
+	}
+
+	for i, f := range from {
+		if !fromMatched[i] {
+			result.Removed = append(result.Removed, f)
+		}
+	}
+	for j, t := range to {
+		if !toMatched[j] {
+			result.Added = append(result.Added, t)
+		}
+	}
+	for _, mappings := range [][]checkMapping{result.Renumbered, result.Reworded} {
+		sort.SliceStable(mappings, func(i, j int) bool {
+			return compareIDs(mappings[i].From.ID, mappings[j].From.ID)
+		})
+	}
+	return result
+}
+
+// compareIDs orders check IDs such as 1.2.10 numerically by their parts.
+func compareIDs(a, b string) bool {
+	ap, bp := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if ap[i] == bp[i] {
+			continue
+		}
+		if len(ap[i]) != len(bp[i]) {
+			return len(ap[i]) < len(bp[i])
+		}
+		return ap[i] < bp[i]
+	}
+	return len(ap) < len(bp)
+}
+
+var (
+	// checkStatusRe matches the (Automated), (Manual), (Scored) and (Not Scored) suffixes of check texts.
+	checkStatusRe = regexp.MustCompile(`(?i)\((automated|manual|scored|not scored)\)`)
+	wordRe        = regexp.MustCompile(`[a-z0-9][a-z0-9\-_./]*`)
+)
+
+// textSimilarity returns the Dice coefficient of the words of the check texts a and b,
+// from 0 for no common word to 1 for the same words.
+func textSimilarity(a, b string) float64 {
+	aw, bw := textWords(a), textWords(b)
+	if len(aw) == 0 && len(bw) == 0 {
+		return 1
+	}
+	common := 0
+	for w, n := range aw {
+		common += min(n, bw[w])
+	}
+	return 2 * float64(common) / float64(countWords(aw)+countWords(bw))
+}
+
+func textWords(s string) map[string]int {
+	s = checkStatusRe.ReplaceAllString(strings.ToLower(s), "")
+	words := make(map[string]int)
+	for _, w := range wordRe.FindAllString(s, -1) {
+		words[strings.TrimRight(w, ".")]++
+	}
+	return words
+}
+
+func countWords(words map[string]int) int {
+	n := 0
+	for _, c := range words {
+		n += c
+	}
+	return n
+}
+
+func printBenchmarkComparison(w io.Writer, from, to string, c benchmarkComparison) {
+	fmt.Fprintf(w, "Comparing %s to %s: %d unchanged, %d reworded, %d renumbered, %d removed, %d added\n",
+		from, to, c.Unchanged, len(c.Reworded), len(c.Renumbered), len(c.Removed), len(c.Added))
+
+	if len(c.Renumbered) > 0 {
+		fmt.Fprintln(w, "\n== Renumbered ==")
+		for _, m := range c.Renumbered {
+			fmt.Fprintf(w, "%s -> %s (%.0f%% similar) %s\n", m.From.ID, m.To.ID, m.Similarity*100, m.To.Text)
+		}
+	}
+	if len(c.Reworded) > 0 {
+		fmt.Fprintln(w, "\n== Reworded ==")
+		for _, m := range c.Reworded {
+			fmt.Fprintf(w, "%s (%.0f%% similar)\n", m.From.ID, m.Similarity*100)
+			fmt.Fprintf(w, "\t - %s\n", m.From.Text)
+			fmt.Fprintf(w, "\t + %s\n", m.To.Text)
+		}
+	}
+	if len(c.Removed) > 0 {
+		fmt.Fprintln(w, "\n== Removed ==")
+		for _, r := range c.Removed {
+			fmt.Fprintf(w, "%s %s\n", r.ID, r.Text)
+		}
+	}
+	if len(c.Added) > 0 {
+		fmt.Fprintln(w, "\n== Added ==")
+		for _, a := range c.Added {
+			fmt.Fprintf(w, "%s %s\n", a.ID, a.Text)
+		}
+	}
+}
+
+func printJSON(v interface{}) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to format output: %v", err))
+	}
+	printOutput(string(out), outputFile)
+}
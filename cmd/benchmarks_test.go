@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{a: "Ensure that the --profiling argument is set to false (Automated)", b: "Ensure that the --profiling argument is set to false (Manual)", min: 1, max: 1},
+		{a: "Ensure that the --profiling argument is set to false", b: "ensure that the --profiling argument is set to false.", min: 1, max: 1},
+		{a: "Ensure that the --authorization-mode argument includes Node", b: "Ensure that the --authorization-mode argument includes RBAC", min: 0.8, max: 0.9},
+		{a: "Minimize access to secrets", b: "Ensure that the --profiling argument is set to false", min: 0, max: 0.2},
+		{a: "", b: "", min: 1, max: 1},
+	}
+	for _, c := range cases {
+		t.Run(c.a, func(t *testing.T) {
+			sim := textSimilarity(c.a, c.b)
+			assert.GreaterOrEqual(t, sim, c.min)
+			assert.LessOrEqual(t, sim, c.max)
+		})
+	}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	from := []benchmarkCheck{
+		{ID: "1.2.1", Text: "Ensure that the --anonymous-auth argument is set to false (Manual)"},
+		{ID: "1.2.2", Text: "Ensure that the --basic-auth-file argument is not set (Automated)"},
+		{ID: "1.2.3", Text: "Ensure that the --authorization-mode argument includes Node (Automated)"},
+		{ID: "1.2.4", Text: "Ensure that the --authorization-mode argument includes RBAC (Automated)"},
+		{ID: "1.2.5", Text: "Ensure that the --profiling argument is set to false (Automated)"},
+	}
+	to := []benchmarkCheck{
+		{ID: "1.2.1", Text: "Ensure that the --anonymous-auth argument is set to false (Automated)"},
+		{ID: "1.2.2", Text: "Ensure that the --authorization-mode argument includes Node (Automated)"},
+		{ID: "1.2.3", Text: "Ensure that the --authorization-mode argument includes RBAC (Automated)"},
+		{ID: "1.2.4", Text: "Ensure that the --profiling argument is set to false, if not needed (Automated)"},
+		{ID: "1.2.5", Text: "Ensure that the --service-account-lookup argument is set to true (Automated)"},
+	}
+
+	c := compareBenchmarks(from, to, defaultSimilarity)
+	assert.Equal(t, 1, c.Unchanged)
+	assert.Equal(t, []benchmarkCheck{from[1]}, c.Removed)
+	assert.Equal(t, []benchmarkCheck{to[4]}, c.Added)
+	assert.Empty(t, c.Reworded)
+
+	var renumbered []string
+	for _, m := range c.Renumbered {
+		renumbered = append(renumbered, m.From.ID+" -> "+m.To.ID)
+	}
+	assert.Equal(t, []string{"1.2.3 -> 1.2.2", "1.2.4 -> 1.2.3", "1.2.5 -> 1.2.4"}, renumbered)
+	assert.Equal(t, 1.0, c.Renumbered[0].Similarity)
+	assert.Less(t, c.Renumbered[2].Similarity, 1.0)
+
+	var buf bytes.Buffer
+	printBenchmarkComparison(&buf, "cis-a", "cis-b", c)
+	out := buf.String()
+	assert.Contains(t, out, "Comparing cis-a to cis-b: 1 unchanged, 0 reworded, 3 renumbered, 1 removed, 1 added\n")
+	assert.Contains(t, out, "1.2.3 -> 1.2.2 (100% similar) Ensure that the --authorization-mode argument includes Node (Automated)\n")
+	assert.Contains(t, out, "== Removed ==\n1.2.2 Ensure that the --basic-auth-file argument is not set (Automated)\n")
+}
+
+func TestCompareBenchmarksReworded(t *testing.T) {
+	from := []benchmarkCheck{{ID: "4.1.4", Text: "Ensure that the proxy kubeconfig file ownership is set to root:root (Manual)"}}
+	to := []benchmarkCheck{{ID: "4.1.4", Text: "If proxy kubeconfig file exists ensure ownership is set to root:root (Manual)"}}
+
+	c := compareBenchmarks(from, to, defaultSimilarity)
+	if assert.Len(t, c.Reworded, 1) {
+		assert.Equal(t, "4.1.4", c.Reworded[0].To.ID)
+	}
+
+	c = compareBenchmarks(from, to, 0.95)
+	assert.Empty(t, c.Reworded)
+	assert.Len(t, c.Removed, 1)
+	assert.Len(t, c.Added, 1)
+}
+
+func TestCompareIDs(t *testing.T) {
+	assert.True(t, compareIDs("1.2.9", "1.2.10"))
+	assert.False(t, compareIDs("1.2.10", "1.2.9"))
+	assert.True(t, compareIDs("1.2", "1.2.1"))
+	assert.True(t, compareIDs("3.1.1", "4.1.1"))
+}
+
+func TestLoadBenchmarkChecks(t *testing.T) {
+	checks, err := loadBenchmarkChecks("../cfg", "cis-1.24-microk8s")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, checks)
+
+	var found *benchmarkCheck
+	for i, c := range checks {
+		if c.ID == "1.1.1" {
+			found = &checks[i]
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.Equal(t, check.MASTER, found.Target)
+		assert.Equal(t, "1.1", found.Group)
+	}
+
+	var buf bytes.Buffer
+	printBenchmarkChecks(&buf, []benchmarkCheck{
+		{ID: "1.1.1", Text: "Ensure that the API server pod specification file permissions are set to 600 or more restrictive (Automated)", Target: check.MASTER, Scored: true},
+		{ID: "3.1.1", Text: "Client certificate authentication should not be used for users (Manual)", Target: check.CONTROLPLANE, Type: "manual"},
+	})
+	assert.Equal(t, `== master ==
+1.1.1 [scored] Ensure that the API server pod specification file permissions are set to 600 or more restrictive (Automated)
+== controlplane ==
+3.1.1 [not scored, manual] Client certificate authentication should not be used for users (Manual)
+`, buf.String())
+
+	_, err = loadBenchmarkChecks("../cfg", "cis-0.1")
+	assert.EqualError(t, err, "benchmark cis-0.1 not found in ../cfg")
+}
+
+func TestListBenchmarks(t *testing.T) {
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+
+	benchmarks, err := listBenchmarks("../cfg", v)
+	if !assert.NoError(t, err) {
+		return
+	}
+	byName := make(map[string]benchmarkInfo)
+	for _, b := range benchmarks {
+		byName[b.Name] = b
+	}
+
+	assert.Equal(t, []string{"1.29", "1.30", "1.31"}, byName["cis-1.11"].KubeVersions)
+	assert.Equal(t, []string{"master", "node", "controlplane", "etcd", "policies"}, byName["cis-1.11"].Targets)
+	assert.Equal(t, []string{"microk8s"}, byName["cis-1.24-microk8s"].Platforms)
+	assert.Equal(t, []string{"ocp-3.10", "ocp-3.11"}, byName["rh-0.7"].KubeVersions)
+	assert.Contains(t, byName["eks-1.7.0"].Platforms, "eks")
+
+	var buf bytes.Buffer
+	printBenchmarkList(&buf, []benchmarkInfo{byName["cis-1.11"]})
+	assert.Equal(t, "cis-1.11\n\t versions: 1.29, 1.30, 1.31\n\t targets: master, node, controlplane, etcd, policies\n", buf.String())
+}
@@ -20,11 +20,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/aquasecurity/kube-bench/check"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
 	"github.com/golang/glog"
 	"github.com/spf13/viper"
 )
@@ -45,6 +47,22 @@ func NewRunFilter(opts FilterOpts) (check.Predicate, error) {
 		checkIDs = cleanIDs(opts.CheckList)
 	}
 
+	var tags, excludeTags map[string]bool
+	if opts.Tags != "" {
+		tags = cleanIDs(opts.Tags)
+	}
+	if opts.ExcludeTags != "" {
+		excludeTags = cleanIDs(opts.ExcludeTags)
+	}
+
+	var expression check.Predicate
+	if opts.Expression != "" {
+		var err error
+		if expression, err = check.CompileFilter(opts.Expression); err != nil {
+			return nil, err
+		}
+	}
+
 	return func(g *check.Group, c *check.Check) bool {
 		test := true
 		if len(groupIDs) > 0 {
@@ -53,12 +71,23 @@ func NewRunFilter(opts FilterOpts) (check.Predicate, error) {
 		}
 
 		if len(checkIDs) > 0 {
-			_, ok := checkIDs[c.ID]
-			test = test && ok
+			test = test && c.MatchesAny(checkIDs)
+		}
+
+		if len(tags) > 0 {
+			test = test && c.HasTag(tags)
+		}
+
+		if len(excludeTags) > 0 {
+			test = test && !c.HasTag(excludeTags)
 		}
 
 		test = test && (opts.Scored && c.Scored || opts.Unscored && !c.Scored)
 
+		if expression != nil {
+			test = test && expression(g, c)
+		}
+
 		return test
 	}, nil
 }
@@ -76,6 +105,7 @@ func runChecks(nodetype check.NodeType, testYamlFile, detectedVersion string) {
 	}
 
 	glog.V(1).Info(fmt.Sprintf("Using test file: %s\n", testYamlFile))
+	recordConfigFile(testYamlFile)
 
 	// Get the viper config for this section of tests
 	typeConf := viper.Sub(string(nodetype))
@@ -99,7 +129,10 @@ func runChecks(nodetype check.NodeType, testYamlFile, detectedVersion string) {
 
 	// Variable substitutions. Replace all occurrences of variables in controls files.
 	s := string(in)
+	pidmap := getPids(binmap)
 	s, binSubs := makeSubstitutions(s, "bin", binmap)
+	binSubs = appendCmdlineSubstitutions(binSubs, s, binmap)
+	s, _ = makeSubstitutions(s, "pid", pidmap)
 	s, _ = makeSubstitutions(s, "conf", confmap)
 	s, _ = makeSubstitutions(s, "svc", svcmap)
 	s, _ = makeSubstitutions(s, "kubeconfig", kubeconfmap)
@@ -111,35 +144,153 @@ func runChecks(nodetype check.NodeType, testYamlFile, detectedVersion string) {
 		exitWithError(fmt.Errorf("error setting up %s controls: %v", nodetype, err))
 	}
 
-	runner := check.NewRunner()
+	controls.VersionSource = kubeVersionSource
+
+	aliases, err := getCheckAliases()
+	if err != nil {
+		exitWithError(err)
+	}
+	benchmark := filepath.Base(filepath.Dir(testYamlFile))
+	controls.ApplyAliases(benchmark, aliases)
+
+	attestations, err := getAttestations()
+	if err != nil {
+		exitWithError(err)
+	}
+	controls.ApplyAttestations(benchmark, attestations)
+
+	runner, err := newCheckRunner(nodetype)
+	if err != nil {
+		exitWithError(fmt.Errorf("error setting up runner: %v", err))
+	}
 	filter, err := NewRunFilter(filterOpts)
 	if err != nil {
 		exitWithError(fmt.Errorf("error setting up run filter: %v", err))
 	}
 
+	generateCmdlineAudit(controls, typeConf.GetStringSlice("components"), binmap, pidmap)
 	generateDefaultEnvAudit(controls, binSubs)
+	if kubeletConfigzAudit && nodetype == check.NODE {
+		source, config := readKubeletConfigz()
+		generateConfigzAudit(controls, confmap["kubelet"], source, config)
+	}
 
 	controls.RunChecks(runner, filter, parseSkipIds(skipIds))
 	controlsCollection = append(controlsCollection, controls)
 }
 
+// checkTagsMatched returns an error when --tags was given and none of the checks run, those left in
+// controlsCollection by the filter, has any of the tags, for example because the benchmark doesn't
+// tag its checks.
+func checkTagsMatched(controlsCollection []*check.Controls) error {
+	if filterOpts.Tags == "" {
+		return nil
+	}
+	tags := cleanIDs(filterOpts.Tags)
+	for _, controls := range controlsCollection {
+		if hasTaggedCheck(controls, tags) {
+			return nil
+		}
+	}
+	benchmark := "the selected benchmark"
+	if len(controlsCollection) > 0 && controlsCollection[0].Version != "" {
+		benchmark = "benchmark " + controlsCollection[0].Version
+	}
+	return fmt.Errorf("--tags %s selects no check of %s", filterOpts.Tags, benchmark)
+}
+
+// hasTaggedCheck returns whether a check of controls has any of tags.
+func hasTaggedCheck(controls *check.Controls, tags map[string]bool) bool {
+	for _, g := range controls.Groups {
+		for _, c := range g.Checks {
+			if c.HasTag(tags) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appendCmdlineSubstitutions adds to binSubs the binaries of the components whose command line
+// is audited with $<component>cmdline in s.
+func appendCmdlineSubstitutions(binSubs []string, s string, binmap map[string]string) []string {
+	for component, bin := range binmap {
+		if strings.Contains(s, "$"+component+"cmdline") {
+			binSubs = appendUnique(binSubs, bin)
+		}
+	}
+	return binSubs
+}
+
+// generateCmdlineAudit replaces $<component>cmdline in audits with a command printing the command
+// line of the component's process, its NUL-terminated arguments as read from /proc, and records the
+// PID of the audited process.
+// If the process isn't visible, the arguments of the component's container reported by the container
+// runtime are printed instead. If neither is found, or the component isn't one of components, the
+// audit fails as the ps audits it replaces did.
+func generateCmdlineAudit(controls *check.Controls, components []string, binmap map[string]string, pidmap map[string]string) {
+	for _, group := range controls.Groups {
+		for _, checkItem := range group.Checks {
+			for _, component := range components {
+				variable := "$" + component + "cmdline"
+				if !strings.Contains(checkItem.Audit, variable) {
+					continue
+				}
+				bin := binmap[component]
+				if bin == "" {
+					bin = component
+				}
+				audit := missingProcessAudit(bin)
+				if pid, ok := pidmap[component]; ok {
+					audit = fmt.Sprintf("cat /proc/%s/cmdline", pid)
+					checkItem.PID, _ = strconv.Atoi(pid)
+				} else if _, ctr := findContainer(containersFunc(), []string{bin}); ctr != nil && len(ctr.Args) > 0 {
+					audit = containerCmdlineAudit(ctr)
+				}
+				checkItem.Audit = strings.ReplaceAll(checkItem.Audit, variable, audit)
+			}
+			checkItem.Audit = cmdlineVariableRe.ReplaceAllStringFunc(checkItem.Audit, func(variable string) string {
+				return missingProcessAudit(cmdlineVariableRe.FindStringSubmatch(variable)[1])
+			})
+		}
+	}
+}
+
+// cmdlineVariableRe matches the $<component>cmdline variables left in an audit.
+var cmdlineVariableRe = regexp.MustCompile(`\$([a-z0-9]+)cmdline`)
+
+// missingProcessAudit returns an audit command that fails, reporting that bin isn't running.
+func missingProcessAudit(bin string) string {
+	if bin == "" {
+		return "{ echo 'no running process found' >&2; false; }"
+	}
+	return fmt.Sprintf("{ echo 'no running process found for %s' >&2; false; }", strings.Trim(bin, "'\""))
+}
+
 func generateDefaultEnvAudit(controls *check.Controls, binSubs []string) {
+	binPath := ""
+	if len(binSubs) == 1 {
+		binPath = binSubs[0]
+	}
+	auditEnv := missingProcessAudit(binPath)
+	if binPath != "" {
+		if found := pidsFunc(binPath); len(found) > 0 {
+			auditEnv = fmt.Sprintf("cat \"/proc/%d/environ\" | tr '\\0' '\\n'", found[0])
+		} else if _, ctr := findContainer(containersFunc(), []string{binPath}); ctr != nil && len(ctr.Env) > 0 {
+			auditEnv = containerEnvAudit(ctr)
+		}
+	}
+
 	for _, group := range controls.Groups {
 		for _, checkItem := range group.Checks {
 			if checkItem.Tests != nil && !checkItem.DisableEnvTesting {
 				for _, test := range checkItem.Tests.TestItems {
 					if test.Env != "" && checkItem.AuditEnv == "" {
-						binPath := ""
-
-						if len(binSubs) == 1 {
-							binPath = binSubs[0]
-						} else {
+						if binPath == "" {
 							glog.V(1).Infof("AuditEnv not explicit for check (%s), where bin path cannot be determined", checkItem.ID)
 						}
 
-						if test.Env != "" && checkItem.AuditEnv == "" {
-							checkItem.AuditEnv = fmt.Sprintf("cat \"/proc/$(/bin/ps -C %s -o pid= | tr -d ' ')/environ\" | tr '\\0' '\\n'", binPath)
-						}
+						checkItem.AuditEnv = auditEnv
 					}
 				}
 			}
@@ -269,6 +420,8 @@ func mergeConfig(path string) error {
 		} else {
 			return fmt.Errorf("couldn't read config file %s: %v", path+"/config.yaml", err)
 		}
+	} else {
+		recordConfigFile(path + "/config.yaml")
 	}
 
 	glog.V(1).Info(fmt.Sprintf("Using config file: %s\n", viper.ConfigFileUsed()))
@@ -313,26 +466,61 @@ func loadTargetMapping(v *viper.Viper) (map[string][]string, error) {
 	return benchmarkVersionToTargetsMap, nil
 }
 
-func getBenchmarkVersion(kubeVersion, benchmarkVersion string, platform Platform, v *viper.Viper) (bv string, err error) {
+// resolveBenchmarkVersion returns the benchmark version selected by --benchmark or --version, or else
+// by the detected platform or Kubernetes version. The Kubernetes version is detected once, and used
+// for both.
+func resolveBenchmarkVersion(v *viper.Viper) (string, error) {
+	kv, err := getKubeVersionUnlessSet(kubeVersion, benchmarkVersion)
+	if err != nil {
+		return "", err
+	}
+
+	var platform Platform
+	if kv != nil {
+		platform = getPlatformInfo(kv)
+	}
+	return getBenchmarkVersion(kubeVersion, benchmarkVersion, kv, platform, v)
+}
+
+// getKubeVersionUnlessSet detects the Kubernetes version, unless kubeVersion or benchmarkVersion
+// already select the benchmark, in which case it returns nil.
+func getKubeVersionUnlessSet(kubeVersion, benchmarkVersion string) (*KubeVersion, error) {
+	if !isEmpty(kubeVersion) || !isEmpty(benchmarkVersion) {
+		return nil, nil
+	}
+	kv, err := getKubeVersion()
+	if err != nil {
+		return nil, fmt.Errorf("Version check failed: %s\nAlternatively, you can specify the version with --version", err)
+	}
+	return kv, nil
+}
+
+// getBenchmarkVersion maps the given versions or platform to a benchmark version. kv is the detected
+// Kubernetes version, which is detected here if it's needed and nil.
+func getBenchmarkVersion(kubeVersion, benchmarkVersion string, kv *KubeVersion, platform Platform, v *viper.Viper) (bv string, err error) {
 	detecetedKubeVersion = "none"
+	kubeVersionSource = ""
 	if !isEmpty(kubeVersion) && !isEmpty(benchmarkVersion) {
 		return "", fmt.Errorf("It is an error to specify both --version and --benchmark flags")
 	}
 	if isEmpty(benchmarkVersion) && isEmpty(kubeVersion) && !isEmpty(platform.Name) {
-		benchmarkVersion = getPlatformBenchmarkVersion(platform)
+		benchmarkVersion = getPlatformBenchmarkVersion(platform, v)
 		if !isEmpty(benchmarkVersion) {
 			detecetedKubeVersion = benchmarkVersion
+			kubeVersionSource = "platform"
 		}
 	}
 
 	if isEmpty(benchmarkVersion) {
 		if isEmpty(kubeVersion) {
-			kv, err := getKubeVersion()
-			if err != nil {
-				return "", fmt.Errorf("Version check failed: %s\nAlternatively, you can specify the version with --version", err)
+			if kv == nil {
+				if kv, err = getKubeVersionUnlessSet(kubeVersion, benchmarkVersion); err != nil {
+					return "", err
+				}
 			}
 			kubeVersion = kv.BaseVersion()
 			detecetedKubeVersion = kubeVersion
+			kubeVersionSource = kv.Source
 		}
 
 		kubeToBenchmarkMap, err := loadVersionMapping(v)
@@ -395,11 +583,23 @@ func exitCodeSelection(controlsCollection []*check.Controls) int {
 }
 
 func writeOutput(controlsCollection []*check.Controls) {
+	if err := loadSigningOptions(); err != nil {
+		exitWithError(err)
+	}
+	if err := checkTagsMatched(controlsCollection); err != nil {
+		exitWithError(err)
+	}
 	sort.Slice(controlsCollection, func(i, j int) bool {
 		iid, _ := strconv.Atoi(controlsCollection[i].ID)
 		jid, _ := strconv.Atoi(controlsCollection[j].ID)
 		return iid < jid
 	})
+	if traceFile != "" {
+		writeTraceOutput(controlsCollection)
+	}
+	if publishNodeResults {
+		writeNodeStatusOutput(controlsCollection)
+	}
 	if junitFmt {
 		writeJunitOutput(controlsCollection)
 		return
@@ -408,6 +608,10 @@ func writeOutput(controlsCollection []*check.Controls) {
 		writeJSONOutput(controlsCollection)
 		return
 	}
+	if ocsfFmt {
+		writeOCSFOutput(controlsCollection)
+		return
+	}
 	if pgSQL {
 		writePgsqlOutput(controlsCollection)
 		return
@@ -416,6 +620,10 @@ func writeOutput(controlsCollection []*check.Controls) {
 		writeASFFOutput(controlsCollection)
 		return
 	}
+	if webhookURL != "" {
+		writeWebhookOutput(controlsCollection)
+		return
+	}
 	writeStdoutOutput(controlsCollection)
 }
 
@@ -426,7 +634,11 @@ func writeJSONOutput(controlsCollection []*check.Controls) {
 		var totals check.OverallControls
 		totals.Controls = controlsCollection
 		totals.Totals = getSummaryTotals(controlsCollection)
-		out, err = json.Marshal(totals)
+		if signingKey != nil {
+			out, err = signedJSONReport(totals, signingKey)
+		} else {
+			out, err = json.Marshal(totals)
+		}
 	} else {
 		out, err = json.Marshal(controlsCollection)
 	}
@@ -452,6 +664,23 @@ func writeJunitOutput(controlsCollection []*check.Controls) {
 	printOutput(prefix+string(outputAllControls)+suffix, outputFile)
 }
 
+func writeOCSFOutput(controlsCollection []*check.Controls) {
+	findings := []check.OCSFFinding{}
+	for _, controls := range controlsCollection {
+		fs, err := controls.OCSF(KubeBenchVersion)
+		if err != nil {
+			exitWithError(fmt.Errorf("failed to format findings as OCSF: %v", err))
+		}
+		findings = append(findings, fs...)
+	}
+
+	out, err := json.Marshal(findings)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to output in OCSF format: %v", err))
+	}
+	printOutput(string(out), outputFile)
+}
+
 func writePgsqlOutput(controlsCollection []*check.Controls) {
 	for _, controls := range controlsCollection {
 		out, err := controls.JSON()
@@ -468,7 +697,13 @@ func writeASFFOutput(controlsCollection []*check.Controls) {
 		if err != nil {
 			exitWithError(fmt.Errorf("failed to format findings as ASFF: %v", err))
 		}
-		if err := writeFinding(out); err != nil {
+		var resolved []types.AwsSecurityFinding
+		if aSFFResolve {
+			if resolved, err = controls.ASFFResolved(); err != nil {
+				exitWithError(fmt.Errorf("failed to format resolved findings as ASFF: %v", err))
+			}
+		}
+		if err := writeFinding(out, resolved); err != nil {
 			exitWithError(fmt.Errorf("failed to output to ASFF: %v", err))
 		}
 	}
@@ -20,12 +20,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aquasecurity/kube-bench/check"
+	"github.com/aquasecurity/kube-bench/internal/cri"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -121,6 +125,76 @@ func TestNewRunFilter(t *testing.T) {
 			Check:      &check.Check{ID: "C2"},
 			Expected:   false,
 		},
+		{
+			Name:       "Should return true when check flag contains check's canonical ID",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, CheckList: "C1,apiserver-profiling"},
+			Group:      &check.Group{},
+			Check:      &check.Check{ID: "C2", Aliases: []string{"apiserver-profiling", "cis-1.8:C3"}},
+			Expected:   true,
+		},
+		{
+			Name:       "Should return true when check flag contains one of check's aliases",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, CheckList: "cis-1.8:C3"},
+			Group:      &check.Group{},
+			Check:      &check.Check{ID: "C2", Aliases: []string{"apiserver-profiling", "cis-1.8:C3"}},
+			Expected:   true,
+		},
+		{
+			Name:       "Should return false when check flag contains the ID of another benchmark's check only",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, CheckList: "C3"},
+			Group:      &check.Group{},
+			Check:      &check.Check{ID: "C2", Aliases: []string{"apiserver-profiling", "cis-1.8:C3"}},
+			Expected:   false,
+		},
+		{
+			Name:       "Should return true when check matches filter expression",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, Expression: `group in (G1,G2) and not id =~ "^C1"`},
+			Group:      &check.Group{ID: "G2"},
+			Check:      &check.Check{ID: "C2"},
+			Expected:   true,
+		},
+		{
+			Name:       "Should return false when check doesn't match filter expression",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, Expression: `group in (G1,G2) and not id =~ "^C1"`},
+			Group:      &check.Group{ID: "G2"},
+			Check:      &check.Check{ID: "C12"},
+			Expected:   false,
+		},
+		{
+			Name:       "Should return false when check matches filter expression but not scored flag",
+			FilterOpts: FilterOpts{Scored: false, Unscored: true, Expression: `group == G2`},
+			Group:      &check.Group{ID: "G2"},
+			Check:      &check.Check{ID: "C2", Scored: true},
+			Expected:   false,
+		},
+		{
+			Name:       "Should return true when check has one of the tags",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, Tags: "tls, audit-logging"},
+			Group:      &check.Group{},
+			Check:      &check.Check{ID: "C2", Tags: []string{"api-server", "tls"}},
+			Expected:   true,
+		},
+		{
+			Name:       "Should return false when check has none of the tags",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, Tags: "tls,audit-logging"},
+			Group:      &check.Group{},
+			Check:      &check.Check{ID: "C2", Tags: []string{"api-server"}},
+			Expected:   false,
+		},
+		{
+			Name:       "Should return false when check has one of the excluded tags",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, Tags: "tls", ExcludeTags: "kubelet"},
+			Group:      &check.Group{},
+			Check:      &check.Check{ID: "C2", Tags: []string{"kubelet", "tls"}},
+			Expected:   false,
+		},
+		{
+			Name:       "Should return true when check has no tags and tags are excluded",
+			FilterOpts: FilterOpts{Scored: true, Unscored: true, ExcludeTags: "kubelet"},
+			Group:      &check.Group{},
+			Check:      &check.Check{ID: "C2"},
+			Expected:   true,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -130,6 +204,11 @@ func TestNewRunFilter(t *testing.T) {
 		})
 	}
 
+	t.Run("Should return error when filter expression is invalid", func(t *testing.T) {
+		_, err := NewRunFilter(FilterOpts{Expression: "group in 1.2"})
+		assert.EqualError(t, err, `filter: expected "(" after in, found "1.2" at position 10`)
+	})
+
 	t.Run("Should return error when both group and check flags are used", func(t *testing.T) {
 		// given
 		opts := FilterOpts{GroupList: "G1", CheckList: "C1"}
@@ -328,6 +407,35 @@ func TestLoadVersionMapping(t *testing.T) {
 	}
 }
 
+func TestGetKubeVersionUnlessSet(t *testing.T) {
+	savedSources, savedStrict := versionSources, strictVersion
+	defer func() { versionSources, strictVersion = savedSources, savedStrict }()
+
+	calls := 0
+	versionSources = []VersionSource{versionSourceFunc{name: "kubectl", version: func() (*KubeVersion, error) {
+		calls++
+		return nil, errors.New("not found")
+	}}}
+
+	kv, err := getKubeVersionUnlessSet("1.29", "")
+	assert.NoError(t, err)
+	assert.Nil(t, kv)
+	kv, err = getKubeVersionUnlessSet("", "cis-1.9")
+	assert.NoError(t, err)
+	assert.Nil(t, kv)
+	assert.Equal(t, 0, calls)
+
+	kv, err = getKubeVersionUnlessSet("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultKubeVersion, kv.BaseVersion())
+	assert.Equal(t, 1, calls)
+
+	strictVersion = true
+	_, err = getKubeVersionUnlessSet("", "")
+	assert.ErrorContains(t, err, "unable to detect the Kubernetes version")
+	assert.Equal(t, 2, calls)
+}
+
 func TestGetBenchmarkVersion(t *testing.T) {
 	viperWithData, err := loadConfigForTest()
 	if err != nil {
@@ -365,6 +473,7 @@ func TestGetBenchmarkVersion(t *testing.T) {
 		kubeVersion      string
 		benchmarkVersion string
 		platform         Platform
+		kv               *KubeVersion
 		v                *viper.Viper
 		callFn           getBenchmarkVersionFn
 		exp              string
@@ -377,9 +486,14 @@ func TestGetBenchmarkVersion(t *testing.T) {
 		{n: "ocpVersion310", kubeVersion: "ocp-3.10", benchmarkVersion: "", platform: Platform{}, v: viperWithData, exp: "rh-0.7", callFn: withNoPath, succeed: true},
 		{n: "ocpVersion311", kubeVersion: "ocp-3.11", benchmarkVersion: "", platform: Platform{}, v: viperWithData, exp: "rh-0.7", callFn: withNoPath, succeed: true},
 		{n: "gke12", kubeVersion: "gke-1.2.0", benchmarkVersion: "", platform: Platform{}, v: viperWithData, exp: "gke-1.2.0", callFn: withNoPath, succeed: true},
+		{n: "microk8s", kubeVersion: "", benchmarkVersion: "", platform: Platform{Name: "microk8s", Version: "1.28"}, v: viperWithData, exp: "cis-1.24-microk8s", callFn: withNoPath, succeed: true},
+		{n: "kind-generic", kubeVersion: "", benchmarkVersion: "", platform: Platform{Name: "kind", Version: "1.18"}, v: viperWithData, exp: "cis-1.6", callFn: withFakeKubectl, succeed: true},
+		{n: "detected version", kubeVersion: "", benchmarkVersion: "", platform: Platform{}, kv: &KubeVersion{Major: "1", Minor: "15"}, v: viperWithData, exp: "cis-1.5", callFn: withFakeKubectl, succeed: true},
 	}
 	for _, c := range cases {
-		rv, err := c.callFn(c.kubeVersion, c.benchmarkVersion, c.platform, c.v, getBenchmarkVersion)
+		rv, err := c.callFn(c.kubeVersion, c.benchmarkVersion, c.platform, c.v, func(kubeVersion, benchmarkVersion string, platform Platform, v *viper.Viper) (string, error) {
+			return getBenchmarkVersion(kubeVersion, benchmarkVersion, c.kv, platform, v)
+		})
 		if c.succeed {
 			if err != nil {
 				t.Errorf("[%q]-Unexpected error: %v", c.n, err)
@@ -647,6 +761,42 @@ func TestExitCodeSelection(t *testing.T) {
 	assert.Equal(t, 10, exitCodeFailure)
 }
 
+func TestCheckTagsMatched(t *testing.T) {
+	defer func(tags string) { filterOpts.Tags = tags }(filterOpts.Tags)
+
+	controls := &check.Controls{Version: "cis-1.9", Groups: []*check.Group{
+		{ID: "4.1", Checks: []*check.Check{{ID: "4.1.1"}, {ID: "4.1.2", Tags: []string{"kubelet"}}}},
+	}}
+	assert.True(t, hasTaggedCheck(controls, cleanIDs("tls,kubelet")))
+	assert.False(t, hasTaggedCheck(controls, cleanIDs("tls")))
+
+	filterOpts.Tags = ""
+	assert.NoError(t, checkTagsMatched([]*check.Controls{controls}))
+
+	filterOpts.Tags = "tls"
+	assert.EqualError(t, checkTagsMatched([]*check.Controls{controls}), "--tags tls selects no check of benchmark cis-1.9")
+	assert.EqualError(t, checkTagsMatched(nil), "--tags tls selects no check of the selected benchmark")
+
+	// Any of the controls run, such as those of another target, can hold the tagged checks.
+	tagged := &check.Controls{Version: "cis-1.9", Groups: []*check.Group{
+		{ID: "1.2", Checks: []*check.Check{{ID: "1.2.1", Tags: []string{"tls"}}}},
+	}}
+	assert.NoError(t, checkTagsMatched([]*check.Controls{controls, tagged}))
+}
+
+func TestCheckTagsMatchedAfterFilter(t *testing.T) {
+	defer func(opts FilterOpts) { filterOpts = opts }(filterOpts)
+
+	controls := &check.Controls{Version: "cis-1.10", Groups: []*check.Group{
+		{ID: "4.1", Checks: []*check.Check{{ID: "4.1.1", Tags: []string{"kubelet"}}, {ID: "4.1.2", Tags: []string{"tls", "kubelet"}}}},
+	}}
+	filterOpts = FilterOpts{Tags: "tls", ExcludeTags: "kubelet"}
+	filter, err := NewRunFilter(filterOpts)
+	assert.NoError(t, err)
+	controls.RunChecks(check.NewRunner(), filter, nil)
+	assert.EqualError(t, checkTagsMatched([]*check.Controls{controls}), "--tags tls selects no check of benchmark cis-1.10")
+}
+
 func TestGenerationDefaultEnvAudit(t *testing.T) {
 	input := []byte(`
 ---
@@ -678,8 +828,147 @@ groups:
 	binSubs := []string{"TestBinPath"}
 	generateDefaultEnvAudit(controls, binSubs)
 
-	expectedAuditEnv := fmt.Sprintf("cat \"/proc/$(/bin/ps -C %s -o pid= | tr -d ' ')/environ\" | tr '\\0' '\\n'", binSubs[0])
-	assert.Equal(t, expectedAuditEnv, controls.Groups[1].Checks[0].AuditEnv)
+	// TestBinPath isn't running, so the audit fails without looking for it with ps
+	assert.Equal(t, "{ echo 'no running process found for TestBinPath' >&2; false; }", controls.Groups[1].Checks[0].AuditEnv)
+
+	controls.Groups[1].Checks[0].AuditEnv = ""
+	generateDefaultEnvAudit(controls, []string{"kube-apiserver", "kube-scheduler"})
+	assert.Equal(t, "{ echo 'no running process found' >&2; false; }", controls.Groups[1].Checks[0].AuditEnv)
+}
+
+func TestGenerationDefaultEnvAuditPid(t *testing.T) {
+	input := []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: G1/C1
+    text: "Verify that the SomeSampleFlag argument is set to true"
+    audit: "grep -B1 SomeSampleFlag=true /this/is/a/file/path"
+    tests:
+      test_items:
+      - flag: "SomeSampleFlag=true"
+        env: "SOME_SAMPLE_FLAG"
+        compare:
+          op: has
+          value: "true"
+        set: true
+    scored: true
+`)
+	controls, err := check.NewControls(check.MASTER, input, "")
+	assert.NoError(t, err)
+
+	oldPidsFunc := pidsFunc
+	defer func() { pidsFunc = oldPidsFunc }()
+	pidsFunc = func(bin string) []int {
+		if bin == "kube-apiserver" {
+			return []int{412, 980}
+		}
+		return nil
+	}
+
+	generateDefaultEnvAudit(controls, []string{"kube-apiserver"})
+	assert.Equal(t, "cat \"/proc/412/environ\" | tr '\\0' '\\n'", controls.Groups[0].Checks[0].AuditEnv)
+}
+
+func TestGenerateCmdlineAudit(t *testing.T) {
+	input := []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: G1/C1
+    text: "Ensure that the --profiling argument is set to false"
+    audit: "$apiservercmdline"
+    tests:
+      test_items:
+      - flag: "--profiling"
+        set: true
+  - id: G1/C2
+    text: "Ensure that the --profiling argument is set to false"
+    audit: "$schedulercmdline | grep -- --profiling"
+    tests:
+      test_items:
+      - flag: "--profiling"
+        set: true
+  - id: G1/C3
+    text: "Ensure that the audit policy file is set"
+    audit: "cat /etc/kubernetes/audit-policy.yaml"
+    tests:
+      test_items:
+      - flag: "--audit-policy-file"
+        set: true
+`)
+	controls, err := check.NewControls(check.MASTER, input, "")
+	assert.NoError(t, err)
+
+	oldContainersFunc := containersFunc
+	defer func() { containersFunc = oldContainersFunc }()
+	containersFunc = func() []cri.Container { return nil }
+
+	binmap := map[string]string{"apiserver": "kube-apiserver", "scheduler": "kube-scheduler"}
+	pidmap := map[string]string{"apiserver": strconv.Itoa(os.Getpid())}
+	generateCmdlineAudit(controls, []string{"apiserver", "scheduler", "controllermanager"}, binmap, pidmap)
+
+	checks := controls.Groups[0].Checks
+	assert.Equal(t, fmt.Sprintf("cat /proc/%d/cmdline", os.Getpid()), checks[0].Audit)
+	assert.Equal(t, os.Getpid(), checks[0].PID)
+	assert.Equal(t, "{ echo 'no running process found for kube-scheduler' >&2; false; } | grep -- --profiling", checks[1].Audit)
+	assert.Zero(t, checks[1].PID)
+	assert.Equal(t, "cat /etc/kubernetes/audit-policy.yaml", checks[2].Audit)
+
+	// The audit reads the command line without ps
+	out, err := exec.Command("/bin/sh", "-c", checks[0].Audit).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join(os.Args, "\x00")+"\x00", string(out))
+	assert.Error(t, exec.Command("/bin/sh", "-c", checks[1].Audit).Run())
+
+	binSubs := appendCmdlineSubstitutions([]string{"kube-apiserver"}, string(input), binmap)
+	assert.ElementsMatch(t, []string{"kube-apiserver", "kube-scheduler"}, binSubs)
+}
+
+func TestGenerateCmdlineAuditMissingProcess(t *testing.T) {
+	controls, err := check.NewControls(check.MASTER, []byte(`
+---
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: G1/C1
+    text: "Ensure that the --token-auth-file parameter is not set"
+    audit: "$apiservercmdline"
+    tests:
+      test_items:
+      - flag: "--token-auth-file"
+        set: false
+    scored: true
+  - id: G1/C2
+    text: "Ensure that the --profiling argument is set to false"
+    audit: "$proxycmdline"
+    tests:
+      test_items:
+      - flag: "--profiling"
+        set: false
+    scored: true
+`), "")
+	assert.NoError(t, err)
+
+	oldContainersFunc := containersFunc
+	defer func() { containersFunc = oldContainersFunc }()
+	containersFunc = func() []cri.Container { return nil }
+
+	// getBinaries returns no binaries when a mandatory component isn't running, and proxy isn't a component of master
+	generateCmdlineAudit(controls, []string{"apiserver", "etcd"}, nil, map[string]string{})
+
+	checks := controls.Groups[0].Checks
+	assert.Equal(t, "{ echo 'no running process found for apiserver' >&2; false; }", checks[0].Audit)
+	assert.Equal(t, "{ echo 'no running process found for proxy' >&2; false; }", checks[1].Audit)
+
+	controls.RunChecks(check.NewRunner(), func(*check.Group, *check.Check) bool { return true }, nil)
+	assert.Equal(t, check.FAIL, checks[0].State)
+	assert.Equal(t, check.FAIL, checks[1].State)
 }
 
 func TestGetSummaryTotals(t *testing.T) {
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/golang/glog"
+	"k8s.io/client-go/kubernetes"
+)
+
+// kubeletConfigz caches the kubelet configuration read from its configz endpoint.
+var kubeletConfigz struct {
+	read   bool
+	source string
+	config string
+}
+
+// kubeletConfigzPath is the API server path proxying to the configz endpoint of the kubelet on nodeName.
+func kubeletConfigzPath(nodeName string) string {
+	return fmt.Sprintf("/api/v1/nodes/%s/proxy/configz", nodeName)
+}
+
+// getKubeletConfigz returns the configuration the kubelet on nodeName is running with,
+// read from its configz endpoint through the API server.
+func getKubeletConfigz(ctx context.Context, k8sClient kubernetes.Interface, nodeName string) (string, error) {
+	raw, err := k8sClient.CoreV1().RESTClient().Get().AbsPath(kubeletConfigzPath(nodeName)).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get kubelet configz of node %s: %v", nodeName, err)
+	}
+	return parseKubeletConfigz(raw)
+}
+
+// parseKubeletConfigz unwraps the KubeletConfiguration from a configz response,
+// so that the paths used against the kubelet config file apply to it unchanged.
+func parseKubeletConfigz(raw []byte) (string, error) {
+	var configz struct {
+		KubeletConfig json.RawMessage `json:"kubeletconfig"`
+	}
+	if err := json.Unmarshal(raw, &configz); err != nil {
+		return "", fmt.Errorf("failed to parse kubelet configz: %v", err)
+	}
+	if len(configz.KubeletConfig) == 0 {
+		return "", fmt.Errorf("kubelet configz has no kubeletconfig")
+	}
+	return string(configz.KubeletConfig), nil
+}
+
+// readKubeletConfigz reads the kubelet configz once per run, returning where it was read from
+// and the configuration, or empty strings if it can't be read.
+func readKubeletConfigz() (string, string) {
+	if kubeletConfigz.read {
+		return kubeletConfigz.source, kubeletConfigz.config
+	}
+	kubeletConfigz.read = true
+
+	k8sClient, err := getInClusterClient()
+	if err != nil {
+		glog.Warningf("Unable to read kubelet configz, not running in a cluster: %v", err)
+		return "", ""
+	}
+	nodeName, err := getNodeName()
+	if err != nil {
+		glog.Warningf("Unable to read kubelet configz, failed to get node name: %v", err)
+		return "", ""
+	}
+	config, err := getKubeletConfigz(context.Background(), k8sClient, nodeName)
+	if err != nil {
+		glog.Warningf("Unable to read kubelet configz: %v", err)
+		return "", ""
+	}
+
+	kubeletConfigz.source = kubeletConfigzPath(nodeName)
+	kubeletConfigz.config = config
+	return kubeletConfigz.source, kubeletConfigz.config
+}
+
+// generateConfigzAudit feeds the kubelet configz to the checks that audit the kubelet config file,
+// where it is used for path tests not found in that file.
+func generateConfigzAudit(controls *check.Controls, kubeletConf, source, config string) {
+	if kubeletConf == "" || config == "" {
+		return
+	}
+	for _, group := range controls.Groups {
+		for _, checkItem := range group.Checks {
+			if checkItem.AuditConfig != "" && strings.Contains(checkItem.AuditConfig, kubeletConf) {
+				checkItem.AuditConfigz = source
+				checkItem.AuditConfigzOutput = config
+			}
+		}
+	}
+}
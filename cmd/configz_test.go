@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestParseKubeletConfigz(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      string
+		expected string
+		fail     bool
+	}{
+		{
+			name:     "kubeletconfig is unwrapped",
+			raw:      `{"kubeletconfig":{"authentication":{"anonymous":{"enabled":false}}}}`,
+			expected: `{"authentication":{"anonymous":{"enabled":false}}}`,
+		},
+		{name: "no kubeletconfig", raw: `{"other":{}}`, fail: true},
+		{name: "not JSON", raw: `404 page not found`, fail: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config, err := parseKubeletConfigz([]byte(c.raw))
+			if c.fail {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, config)
+		})
+	}
+}
+
+func TestGetKubeletConfigz(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/nodes/node-1/proxy/configz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kubeletconfig":{"readOnlyPort":0}}`))
+	}))
+	defer srv.Close()
+
+	k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	assert.NoError(t, err)
+
+	config, err := getKubeletConfigz(context.Background(), k8sClient, "node-1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"readOnlyPort":0}`, config)
+
+	_, err = getKubeletConfigz(context.Background(), k8sClient, "node-2")
+	assert.Error(t, err)
+}
+
+func TestGenerateConfigzAudit(t *testing.T) {
+	kubeletCheck := &check.Check{ID: "4.2.1", AuditConfig: "/bin/cat /var/lib/kubelet/config.yaml"}
+	proxyCheck := &check.Check{ID: "4.1.3", AuditConfig: "/bin/cat /etc/kubernetes/proxy.conf"}
+	flagCheck := &check.Check{ID: "4.2.2"}
+	controls := &check.Controls{Groups: []*check.Group{{Checks: []*check.Check{kubeletCheck, proxyCheck, flagCheck}}}}
+
+	generateConfigzAudit(controls, "/var/lib/kubelet/config.yaml", "/api/v1/nodes/node-1/proxy/configz", `{"readOnlyPort":0}`)
+
+	assert.Equal(t, "/api/v1/nodes/node-1/proxy/configz", kubeletCheck.AuditConfigz)
+	assert.Equal(t, `{"readOnlyPort":0}`, kubeletCheck.AuditConfigzOutput)
+	assert.Empty(t, proxyCheck.AuditConfigzOutput)
+	assert.Empty(t, flagCheck.AuditConfigzOutput)
+}
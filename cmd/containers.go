@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/internal/cri"
+	"github.com/golang/glog"
+)
+
+const criTimeout = 10 * time.Second
+
+// runtimeContainers caches the containers read from the container runtime.
+var runtimeContainers struct {
+	read       bool
+	containers []cri.Container
+}
+
+// readContainers lists the running containers through the CRI socket once per run,
+// or returns none if no container runtime can be reached.
+func readContainers() []cri.Container {
+	if runtimeContainers.read {
+		return runtimeContainers.containers
+	}
+	runtimeContainers.read = true
+
+	endpoints := cri.DefaultEndpoints
+	if criEndpoint != "" {
+		endpoints = []string{criEndpoint}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), criTimeout)
+	defer cancel()
+	client, err := cri.Connect(ctx, endpoints)
+	if err != nil {
+		glog.V(1).Info(fmt.Sprintf("Unable to discover components running in containers: %v", err))
+		return nil
+	}
+	defer client.Close()
+
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		glog.V(1).Info(fmt.Sprintf("Unable to discover components running in containers: %v", err))
+		return nil
+	}
+	glog.V(2).Info(fmt.Sprintf("Found %d running containers through %s", len(containers), client.Endpoint()))
+
+	runtimeContainers.containers = containers
+	return containers
+}
+
+// findContainer looks through a list of possible executable names and finds the first one
+// that's running in a container, either as the container name or as the container command.
+func findContainer(containers []cri.Container, candidates []string) (string, *cri.Container) {
+	for _, c := range candidates {
+		bin := strings.Trim(c, "'\"")
+		for i, ctr := range containers {
+			if ctr.Name == bin || matchesBin(bin, ctr.CommandLine()) {
+				glog.V(2).Info(fmt.Sprintf("executable '%s' running in container %s of pod %s/%s", c, ctr.Name, ctr.PodNamespace, ctr.PodName))
+				return c, &containers[i]
+			}
+		}
+	}
+	return "", nil
+}
+
+// findContainerFile looks through a list of possible files inside ctr and returns
+// the host path of the first one that is mounted from the host and exists.
+func findContainerFile(ctr *cri.Container, candidates []string) string {
+	for _, c := range candidates {
+		hostPath := ctr.HostPath(c)
+		if hostPath == "" {
+			continue
+		}
+		if file := findConfigFile([]string{hostPath}); file != "" {
+			return file
+		}
+	}
+	return ""
+}
+
+// containerEnvAudit returns an audit command printing the environment of ctr, one variable per row,
+// as the default env audit does for processes.
+func containerEnvAudit(ctr *cri.Container) string {
+	return "printf '%s\\n' " + shellQuote(ctr.Env)
+}
+
+// containerCmdlineAudit returns an audit command printing the command line of ctr, its arguments
+// terminated by NULs, as the $<component>cmdline audit does for processes.
+func containerCmdlineAudit(ctr *cri.Container) string {
+	return "printf '%s\\0' " + shellQuote(ctr.Args)
+}
+
+// shellQuote quotes each of args for the shell, and joins them with spaces.
+func shellQuote(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, a := range args {
+		quoted = append(quoted, "'"+strings.ReplaceAll(a, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
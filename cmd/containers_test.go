@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/aquasecurity/kube-bench/internal/cri"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+var fakeContainers = []cri.Container{
+	{
+		Name:         "etcd",
+		PodName:      "etcd-kind-control-plane",
+		PodNamespace: "kube-system",
+		Args:         []string{"etcd", "--data-dir=/var/lib/etcd"},
+	},
+	{
+		Name:         "kube-apiserver",
+		PodName:      "kube-apiserver-kind-control-plane",
+		PodNamespace: "kube-system",
+		Args:         []string{"/usr/local/bin/kube-apiserver", "--profiling=false"},
+		Env:          []string{"PATH=/bin", "MOTD=it's up"},
+	},
+}
+
+func TestFindContainer(t *testing.T) {
+	cases := []struct {
+		candidates []string
+		expBin     string
+		expName    string
+	}{
+		{candidates: []string{"apiserver", "kube-apiserver"}, expBin: "kube-apiserver", expName: "kube-apiserver"},
+		{candidates: []string{"etcd"}, expBin: "etcd", expName: "etcd"},
+		{candidates: []string{"kube-scheduler"}},
+	}
+	for _, c := range cases {
+		t.Run(c.expBin, func(t *testing.T) {
+			bin, ctr := findContainer(fakeContainers, c.candidates)
+			assert.Equal(t, c.expBin, bin)
+			if c.expName == "" {
+				assert.Nil(t, ctr)
+			} else if assert.NotNil(t, ctr) {
+				assert.Equal(t, c.expName, ctr.Name)
+			}
+		})
+	}
+}
+
+func TestContainerEnvAudit(t *testing.T) {
+	audit := containerEnvAudit(&fakeContainers[1])
+	assert.Equal(t, `printf '%s\n' 'PATH=/bin' 'MOTD=it'\''s up'`, audit)
+
+	out, err := exec.Command("/bin/sh", "-c", audit).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "PATH=/bin\nMOTD=it's up\n", string(out))
+}
+
+func TestContainerCmdlineAudit(t *testing.T) {
+	ctr := cri.Container{Args: []string{"kube-apiserver", "--profiling=false", "--motd=it's up"}}
+	audit := containerCmdlineAudit(&ctr)
+	assert.Equal(t, `printf '%s\0' 'kube-apiserver' '--profiling=false' '--motd=it'\''s up'`, audit)
+
+	out, err := exec.Command("/bin/sh", "-c", audit).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "kube-apiserver\x00--profiling=false\x00--motd=it's up\x00", string(out))
+}
+
+func TestCmdlineAuditContainer(t *testing.T) {
+	controls, err := check.NewControls(check.MASTER, []byte(`
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: G1/C1
+    text: "Ensure that the --profiling argument is set to false"
+    audit: "$apiservercmdline"
+    tests:
+      test_items:
+      - flag: "--profiling"
+        compare:
+          op: eq
+          value: false
+        set: true
+    scored: true
+`), "")
+	assert.NoError(t, err)
+
+	oldContainersFunc := containersFunc
+	defer func() { containersFunc = oldContainersFunc }()
+	containersFunc = func() []cri.Container { return fakeContainers }
+
+	// kube-apiserver isn't visible as a process, only as a container of the container runtime
+	generateCmdlineAudit(controls, []string{"apiserver"}, map[string]string{"apiserver": "kube-apiserver"}, map[string]string{})
+	c := controls.Groups[0].Checks[0]
+	assert.Equal(t, containerCmdlineAudit(&fakeContainers[1]), c.Audit)
+
+	controls.RunChecks(check.NewRunner(), func(*check.Group, *check.Check) bool { return true }, nil)
+	assert.Equal(t, check.PASS, c.State)
+	assert.Equal(t, "'--profiling' is equal to 'false'", c.ExpectedResult)
+}
+
+func TestGetBinariesFromContainers(t *testing.T) {
+	oldContainersFunc := containersFunc
+	defer func() { containersFunc = oldContainersFunc }()
+	containersFunc = func() []cri.Container { return fakeContainers }
+	psFunc = fakeps
+	g = ""
+
+	v := viper.New()
+	v.Set("components", []string{"apiserver", "etcd", "scheduler"})
+	v.Set("apiserver", map[string]interface{}{"bins": []string{"apiserver", "kube-apiserver"}})
+	v.Set("etcd", map[string]interface{}{"bins": []string{"etcd"}})
+	v.Set("scheduler", map[string]interface{}{"bins": []string{"kube-scheduler"}, "optional": true})
+
+	m, err := getBinaries(v, check.MASTER)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"apiserver": "kube-apiserver", "etcd": "etcd", "scheduler": "scheduler"}, m)
+}
+
+func TestGetFilesFromContainerMounts(t *testing.T) {
+	hostDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(hostDir, "apiserver.yaml"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldContainersFunc := containersFunc
+	defer func() { containersFunc = oldContainersFunc }()
+	ctr := fakeContainers[1]
+	ctr.Mounts = []cri.Mount{{ContainerPath: "/etc/kubernetes/config", HostPath: hostDir}}
+	containersFunc = func() []cri.Container { return []cri.Container{ctr} }
+	statFunc = os.Stat
+
+	v := viper.New()
+	v.Set("components", []string{"apiserver", "etcd"})
+	v.Set("apiserver", map[string]interface{}{
+		"bins":  []string{"kube-apiserver"},
+		"confs": []string{"/nonexistent/apiserver.yaml", "/etc/kubernetes/config/apiserver.yaml"},
+	})
+	v.Set("etcd", map[string]interface{}{
+		"bins":        []string{"etcd"},
+		"confs":       []string{"/etc/kubernetes/config/etcd.yaml"},
+		"defaultconf": "/etc/etcd/etcd.conf",
+	})
+
+	m := getFiles(v, "config")
+	assert.Equal(t, map[string]string{
+		"apiserver": filepath.Join(hostDir, "apiserver.yaml"),
+		"etcd":      "/etc/etcd/etcd.conf",
+	}, m)
+}
+
+func TestGenerationDefaultEnvAuditContainer(t *testing.T) {
+	controls, err := check.NewControls(check.MASTER, []byte(`
+type: "master"
+groups:
+- id: G1
+  checks:
+  - id: G1/C1
+    text: "Verify that MOTD is set"
+    tests:
+      test_items:
+      - env: "MOTD"
+        set: true
+`), "")
+	assert.NoError(t, err)
+
+	oldPidsFunc, oldContainersFunc := pidsFunc, containersFunc
+	defer func() { pidsFunc, containersFunc = oldPidsFunc, oldContainersFunc }()
+	pidsFunc = func(string) []int { return nil }
+	containersFunc = func() []cri.Container { return fakeContainers }
+
+	generateDefaultEnvAudit(controls, []string{"kube-apiserver"})
+	assert.Equal(t, containerEnvAudit(&fakeContainers[1]), controls.Groups[0].Checks[0].AuditEnv)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RootCmd.AddCommand(detectCmd)
+}
+
+// detectCmd represents the detect command
+var detectCmd = &cobra.Command{
+	Use:   "detect",
+	Short: "Show the platform kube-bench detects and why",
+	Long: `Run the platform detectors and print the platform detected with the highest confidence,
+the evidence found for each platform, and the benchmark version that would be used.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		kv, err := getKubeVersionUnlessSet(kubeVersion, benchmarkVersion)
+		if err != nil {
+			exitWithError(fmt.Errorf("unable to get benchmark version. error: %v", err))
+		}
+
+		detections, err := getPlatformDetections(viper.GetViper(), kv)
+		if err != nil {
+			exitWithError(fmt.Errorf("unable to detect platform: %v", err))
+		}
+
+		bv, err := getBenchmarkVersion(kubeVersion, benchmarkVersion, kv, bestPlatform(detections), viper.GetViper())
+		if err != nil {
+			exitWithError(fmt.Errorf("unable to get benchmark version. error: %v", err))
+		}
+
+		printPlatformDetections(os.Stdout, detections, bv)
+	},
+}
+
+// printPlatformDetections prints the platform detections, ordered by decreasing confidence,
+// and the benchmark version selected.
+func printPlatformDetections(w io.Writer, detections []PlatformDetection, benchmark string) {
+	if len(detections) == 0 || detections[0].Confidence == 0 {
+		fmt.Fprintln(w, "Platform: none detected")
+	} else {
+		best := detections[0]
+		fmt.Fprintf(w, "Platform: %s %s (confidence %d)\n", best.Platform.Name, best.Platform.Version, best.Confidence)
+	}
+	fmt.Fprintf(w, "Benchmark: %s\n", benchmark)
+	fmt.Fprintln(w)
+
+	var undetected []string
+	for _, d := range detections {
+		if d.Confidence == 0 && len(d.Evidence) == 0 {
+			undetected = append(undetected, d.Platform.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%s: confidence %d\n", d.Platform.Name, d.Confidence)
+		for _, e := range d.Evidence {
+			fmt.Fprintf(w, "\t %s\n", e)
+		}
+	}
+	if len(undetected) > 0 {
+		fmt.Fprintf(w, "No evidence found for: %s\n", strings.Join(undetected, ", "))
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RootCmd.AddCommand(explainCmd)
+}
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Run a single check and explain how it was evaluated",
+	Long: `Run a single check, given with --check, and print every step of its evaluation:
+the audit commands after variable substitution and their output, the audit source each test item used,
+the value found for each test item, its comparison result, and how bin_op combined the results.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if filterOpts.GroupList != "" {
+			exitWithError(fmt.Errorf("explain does not support --group"))
+		}
+		ids := cleanIDs(filterOpts.CheckList)
+		if filterOpts.CheckList == "" || len(ids) != 1 {
+			exitWithError(fmt.Errorf("explain needs exactly one check, for example --check 1.2.5"))
+		}
+
+		bv, err := resolveBenchmarkVersion(viper.GetViper())
+		if err != nil {
+			exitWithError(fmt.Errorf("unable to get benchmark version. error: %v", err))
+		}
+
+		path := filepath.Join(cfgDir, bv)
+		err = mergeConfig(path)
+		if err != nil {
+			exitWithError(fmt.Errorf("Error in mergeConfig: %v\n", err))
+		}
+
+		yamlFiles, err := getTestYamlFiles(nil, bv)
+		if err != nil {
+			exitWithError(err)
+		}
+		for _, yamlFile := range yamlFiles {
+			_, name := filepath.Split(yamlFile)
+			testType := check.NodeType(strings.Split(name, ".")[0])
+			runChecks(testType, yamlFile, detecetedKubeVersion)
+		}
+
+		controls, c := findRunCheck(controlsCollection)
+		if c == nil {
+			exitWithError(fmt.Errorf("check %s not found in benchmark %s", filterOpts.CheckList, bv))
+		}
+		glog.V(2).Infof("Explaining check %s of benchmark %s", c.ID, bv)
+		explainCheck(os.Stdout, bv, controls, c)
+	},
+}
+
+// findRunCheck returns the first check that was run, and its controls.
+func findRunCheck(controlsCollection []*check.Controls) (*check.Controls, *check.Check) {
+	for _, controls := range controlsCollection {
+		for _, g := range controls.Groups {
+			for _, c := range g.Checks {
+				if c.Trace != nil {
+					return controls, c
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// explainCheck prints the evaluation trace of c in human-readable format.
+func explainCheck(w io.Writer, benchmark string, controls *check.Controls, c *check.Check) {
+	tr := c.Trace
+
+	fmt.Fprintf(w, "== Check %s ==\n", c.ID)
+	fmt.Fprintf(w, "%s\n", c.Text)
+	fmt.Fprintf(w, "Benchmark: %s, node type: %s, scored: %t", benchmark, controls.Type, c.Scored)
+	if c.Type != "" {
+		fmt.Fprintf(w, ", type: %s", c.Type)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "== Audit ==")
+	if len(tr.Audits) == 0 {
+		fmt.Fprintln(w, "No audit commands were run")
+	}
+	for _, a := range tr.Audits {
+		fmt.Fprintf(w, "[%s] exit status %d in %.1fms\n", a.Source, a.ExitStatus, a.DurationMs)
+		fmt.Fprintln(w, "command:")
+		printIndented(w, a.Command)
+		fmt.Fprintln(w, "output:")
+		printIndented(w, a.Output)
+		if a.Error != "" {
+			fmt.Fprintf(w, "error: %s\n", a.Error)
+		}
+	}
+	fmt.Fprintln(w)
+
+	if len(tr.Tests) > 0 {
+		fmt.Fprintln(w, "== Tests ==")
+		for i, t := range tr.Tests {
+			fmt.Fprintf(w, "%d. %s\n", i+1, describeTestTrace(t))
+			for _, a := range t.Attempts {
+				fmt.Fprintf(w, "   %s: ", a.Source)
+				if a.Found {
+					fmt.Fprintf(w, "found %q", a.Value)
+				} else {
+					fmt.Fprint(w, "not found")
+				}
+				fmt.Fprintf(w, " -> %t (%s)\n", a.Result, a.ExpectedResult)
+			}
+			if len(t.Attempts) > 1 {
+				fmt.Fprintf(w, "   fell back to %s -> %t\n", t.AuditUsed, t.Result)
+			} else {
+				fmt.Fprintf(w, "   used %s -> %t\n", t.AuditUsed, t.Result)
+			}
+		}
+		results := make([]string, 0, len(tr.Tests))
+		for _, t := range tr.Tests {
+			results = append(results, fmt.Sprintf("%t", t.Result))
+		}
+		fmt.Fprintf(w, "bin_op %s: %s = %t\n", tr.BinOp, strings.Join(results, " "+strings.ToUpper(tr.BinOp)+" "), tr.BinOpResult)
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "== Result ==")
+	fmt.Fprintf(w, "[%s] %s %s\n", tr.State, c.ID, c.Text)
+	if c.ExpectedResult != "" {
+		fmt.Fprintf(w, "expected: %s\n", c.ExpectedResult)
+	}
+	if tr.Reason != "" {
+		fmt.Fprintf(w, "reason: %s\n", tr.Reason)
+	}
+}
+
+func describeTestTrace(t check.TestTrace) string {
+	var sources []string
+	if t.Flag != "" {
+		sources = append(sources, fmt.Sprintf("flag %q", t.Flag))
+	}
+	if t.Path != "" {
+		sources = append(sources, fmt.Sprintf("path %q", t.Path))
+	}
+	if t.Env != "" {
+		sources = append(sources, fmt.Sprintf("env %q", t.Env))
+	}
+	if t.Container != "" {
+		sources = append(sources, fmt.Sprintf("container %q", t.Container))
+	}
+	if t.Match != "" {
+		sources = append(sources, fmt.Sprintf("match %s", t.Match))
+	}
+
+	s := strings.Join(sources, ", ") + fmt.Sprintf(" set: %t", t.Set)
+	if t.Op != "" {
+		s += fmt.Sprintf(", compare: %s %q", t.Op, t.CompareValue)
+	}
+	return s
+}
+
+func printIndented(w io.Writer, s string) {
+	for _, row := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		fmt.Fprintf(w, "\t %s\n", row)
+	}
+}
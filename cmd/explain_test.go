@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainCheck(t *testing.T) {
+	controls, err := check.NewControls(check.MASTER, []byte(`
+type: "master"
+groups:
+- id: 1.2
+  checks:
+  - id: 1.2.4
+    text: "Not run"
+    audit: "echo '--profiling=false'"
+  - id: 1.2.5
+    text: "Ensure that the --kubelet-certificate-authority argument is set as appropriate"
+    audit: "echo '--profiling=false'"
+    audit_config: "echo 'kubeletCertificateAuthority: /etc/ca.crt'"
+    tests:
+      bin_op: or
+      test_items:
+      - flag: "--kubelet-certificate-authority"
+        path: "{.kubeletCertificateAuthority}"
+        compare:
+          op: eq
+          value: /etc/ca.crt
+      - flag: "--profiling"
+        compare:
+          op: eq
+          value: true
+    scored: true
+`), "")
+	assert.NoError(t, err)
+
+	filter := func(g *check.Group, c *check.Check) bool { return c.ID == "1.2.5" }
+	controls.RunChecks(check.NewRunner(), filter, nil)
+
+	got, c := findRunCheck([]*check.Controls{controls})
+	if !assert.NotNil(t, c) {
+		return
+	}
+	assert.Equal(t, controls, got)
+	assert.Equal(t, "1.2.5", c.ID)
+
+	var buf bytes.Buffer
+	explainCheck(&buf, "cis-1.9", controls, c)
+	out := buf.String()
+
+	assert.Contains(t, out, "== Check 1.2.5 ==")
+	assert.Contains(t, out, "Benchmark: cis-1.9, node type: master, scored: true")
+	assert.Contains(t, out, "[auditCommand] exit status 0")
+	assert.Contains(t, out, "\t echo 'kubeletCertificateAuthority: /etc/ca.crt'")
+	assert.Contains(t, out, "1. flag \"--kubelet-certificate-authority\", path \"{.kubeletCertificateAuthority}\" set: true, compare: eq \"/etc/ca.crt\"")
+	assert.Contains(t, out, "   auditCommand: not found")
+	assert.Contains(t, out, "   auditConfig: found \"/etc/ca.crt\" -> true")
+	assert.Contains(t, out, "   fell back to auditConfig -> true")
+	assert.Contains(t, out, "   auditCommand: found \"false\" -> false")
+	assert.Contains(t, out, "bin_op or: true OR false = true")
+	assert.Contains(t, out, "[PASS] 1.2.5")
+}
+
+func TestFindRunCheckNotFound(t *testing.T) {
+	controls := &check.Controls{Groups: []*check.Group{{Checks: []*check.Check{{ID: "1.1.1"}}}}}
+	_, c := findRunCheck([]*check.Controls{controls})
+	assert.Nil(t, c)
+}
@@ -19,6 +19,8 @@ type KubeVersion struct {
 	Minor       string
 	baseVersion string
 	GitVersion  string
+	// Source is the name of the version source that found the version.
+	Source string
 }
 
 func (k *KubeVersion) BaseVersion() string {
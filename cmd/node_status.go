@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/golang/glog"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// NodeConditionCompliant is the type of the Node condition holding the benchmark result.
+	NodeConditionCompliant corev1.NodeConditionType = "CISBenchmarkCompliant"
+
+	eventSourceComponent = "kube-bench"
+	eventReasonFailed    = "CISBenchmarkCheckFailed"
+	eventsNamespace      = metav1.NamespaceDefault
+)
+
+var invalidEventNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// getNodeName returns the name of the node kube-bench is running on.
+func getNodeName() (string, error) {
+	if name := viper.GetString("NODE_NAME"); name != "" {
+		return name, nil
+	}
+	if name := os.Getenv("NODE_NAME"); name != "" {
+		return name, nil
+	}
+	return os.Hostname()
+}
+
+func writeNodeStatusOutput(controlsCollection []*check.Controls) {
+	k8sClient, err := getInClusterClient()
+	if err != nil {
+		glog.Warningf("Unable to publish results to Kubernetes, not running in a cluster: %v", err)
+		return
+	}
+
+	nodeName, err := getNodeName()
+	if err != nil {
+		glog.Warningf("Unable to publish results to Kubernetes, failed to get node name: %v", err)
+		return
+	}
+
+	if err := publishNodeStatus(context.Background(), k8sClient, nodeName, controlsCollection); err != nil {
+		glog.Warningf("Failed to publish results to Kubernetes: %v", err)
+	}
+}
+
+// publishNodeStatus records an Event on the node for every failing check,
+// and sets the CISBenchmarkCompliant condition of the node from the totals.
+func publishNodeStatus(ctx context.Context, k8sClient kubernetes.Interface, nodeName string, controlsCollection []*check.Controls) error {
+	node, err := k8sClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+
+	now := metav1.Now()
+	for _, controls := range controlsCollection {
+		for _, g := range controls.Groups {
+			for _, c := range g.Checks {
+				if c.State != check.FAIL {
+					continue
+				}
+				if err := recordCheckEvent(ctx, k8sClient, node, c, now); err != nil {
+					return fmt.Errorf("failed to record event for check %s: %v", c.ID, err)
+				}
+			}
+		}
+	}
+
+	// The kubelet updates the node status every few seconds, so the condition is set on
+	// the latest node, read again when the update conflicts with one of the kubelet.
+	summary := getSummaryTotals(controlsCollection)
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := k8sClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		setComplianceCondition(node, summary, now)
+		_, err = k8sClient.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update status of node %s: %v", nodeName, err)
+	}
+
+	glog.V(2).Info(fmt.Sprintf("successfully published results to node: %s", nodeName))
+	return nil
+}
+
+// recordCheckEvent creates the Event of a failing check, or, like the client-go
+// EventRecorder, bumps the count of the Event left by an earlier run, so that a
+// periodic job keeps a single Event per node and check.
+func recordCheckEvent(ctx context.Context, k8sClient kubernetes.Interface, node *corev1.Node, c *check.Check, now metav1.Time) error {
+	events := k8sClient.CoreV1().Events(eventsNamespace)
+	event := newCheckEvent(node, c, now)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := events.Get(ctx, event.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = events.Create(ctx, event, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		existing.Count++
+		existing.LastTimestamp = now
+		existing.Message = event.Message
+		_, err = events.Update(ctx, existing, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func checkEventName(node *corev1.Node, c *check.Check) string {
+	id := invalidEventNameChars.ReplaceAllString(strings.ToLower(c.ID), "-")
+	return fmt.Sprintf("%s.kube-bench.%s", node.Name, id)
+}
+
+func newCheckEvent(node *corev1.Node, c *check.Check, now metav1.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      checkEventName(node, c),
+			Namespace: eventsNamespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Node",
+			Name:       node.Name,
+			UID:        node.UID,
+		},
+		Reason:         eventReasonFailed,
+		Message:        fmt.Sprintf("%s %s", c.ID, c.Text),
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: eventSourceComponent, Host: node.Name},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+}
+
+func setComplianceCondition(node *corev1.Node, summary check.Summary, now metav1.Time) {
+	condition := corev1.NodeCondition{
+		Type:              NodeConditionCompliant,
+		Status:            corev1.ConditionTrue,
+		Reason:            "ChecksPassed",
+		LastHeartbeatTime: now,
+		Message: fmt.Sprintf("%d checks PASS, %d checks FAIL, %d checks WARN, %d checks INFO",
+			summary.Pass, summary.Fail, summary.Warn, summary.Info),
+	}
+	if summary.Fail > 0 {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "ChecksFailed"
+	}
+
+	for i, existing := range node.Status.Conditions {
+		if existing.Type != NodeConditionCompliant {
+			continue
+		}
+		condition.LastTransitionTime = existing.LastTransitionTime
+		if existing.Status != condition.Status {
+			condition.LastTransitionTime = now
+		}
+		node.Status.Conditions[i] = condition
+		return
+	}
+
+	condition.LastTransitionTime = now
+	node.Status.Conditions = append(node.Status.Conditions, condition)
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func nodeStatusControls(states ...check.State) []*check.Controls {
+	g := &check.Group{ID: "4.2"}
+	for i, s := range states {
+		g.Checks = append(g.Checks, &check.Check{ID: "4.2." + string(rune('1'+i)), Text: "check", State: s})
+	}
+	controls := &check.Controls{ID: "4", Groups: []*check.Group{g}}
+	for _, s := range states {
+		switch s {
+		case check.PASS:
+			controls.Pass++
+		case check.FAIL:
+			controls.Fail++
+		case check.WARN:
+			controls.Warn++
+		}
+	}
+	return []*check.Controls{controls}
+}
+
+func getCompliantCondition(node *corev1.Node) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == NodeConditionCompliant {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestPublishNodeStatus(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	client := fake.NewSimpleClientset(node)
+
+	err := publishNodeStatus(ctx, client, "node-1", nodeStatusControls(check.PASS, check.FAIL, check.WARN, check.FAIL))
+	assert.NoError(t, err)
+
+	events, err := client.CoreV1().Events(eventsNamespace).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, events.Items, 2)
+	for _, e := range events.Items {
+		assert.Equal(t, "Node", e.InvolvedObject.Kind)
+		assert.Equal(t, "node-1", e.InvolvedObject.Name)
+		assert.Equal(t, node.UID, e.InvolvedObject.UID)
+		assert.Equal(t, corev1.EventTypeWarning, e.Type)
+		assert.Equal(t, eventReasonFailed, e.Reason)
+		assert.Equal(t, int32(1), e.Count)
+	}
+
+	updated, err := client.CoreV1().Nodes().Get(ctx, "node-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Status.Conditions, 2)
+	condition := getCompliantCondition(updated)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, corev1.ConditionFalse, condition.Status)
+		assert.Equal(t, "ChecksFailed", condition.Reason)
+		assert.Contains(t, condition.Message, "2 checks FAIL")
+	}
+
+	// A second, passing run updates the existing condition in place.
+	err = publishNodeStatus(ctx, client, "node-1", nodeStatusControls(check.PASS, check.WARN))
+	assert.NoError(t, err)
+	updated, err = client.CoreV1().Nodes().Get(ctx, "node-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Status.Conditions, 2)
+	condition = getCompliantCondition(updated)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, corev1.ConditionTrue, condition.Status)
+		assert.Equal(t, "ChecksPassed", condition.Reason)
+	}
+}
+
+func TestPublishNodeStatusRepeatedFailure(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-1"}}
+	client := fake.NewSimpleClientset(node)
+
+	// Every run of a periodic job reports the same failing checks.
+	for i := 0; i < 3; i++ {
+		err := publishNodeStatus(ctx, client, "node-1", nodeStatusControls(check.FAIL, check.PASS, check.FAIL))
+		assert.NoError(t, err)
+	}
+
+	events, err := client.CoreV1().Events(eventsNamespace).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, events.Items, 2)
+	names := []string{}
+	for _, e := range events.Items {
+		names = append(names, e.Name)
+		assert.Equal(t, int32(3), e.Count)
+		assert.False(t, e.LastTimestamp.Before(&e.FirstTimestamp))
+	}
+	assert.ElementsMatch(t, []string{"node-1.kube-bench.4.2.1", "node-1.kube-bench.4.2.3"}, names)
+}
+
+func TestPublishNodeStatusConflict(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-1"}}
+	client := fake.NewSimpleClientset(node)
+
+	// The kubelet updates the node status between the read and the first update.
+	conflicts := 0
+	client.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "status" || conflicts > 0 {
+			return false, nil, nil
+		}
+		conflicts++
+		kubeletNode := node.DeepCopy()
+		kubeletNode.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+		if err := client.Tracker().Update(schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, kubeletNode, ""); err != nil {
+			return true, nil, err
+		}
+		return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node-1", nil)
+	})
+
+	err := publishNodeStatus(ctx, client, "node-1", nodeStatusControls(check.FAIL))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, conflicts)
+
+	events, err := client.CoreV1().Events(eventsNamespace).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, events.Items, 1)
+
+	updated, err := client.CoreV1().Nodes().Get(ctx, "node-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Status.Conditions, 2)
+	condition := getCompliantCondition(updated)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	}
+}
+
+func TestPublishNodeStatusMissingNode(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	err := publishNodeStatus(context.Background(), client, "missing", nodeStatusControls(check.FAIL))
+	assert.Error(t, err)
+}
+
+func TestSetComplianceCondition(t *testing.T) {
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+	node := &corev1.Node{
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: NodeConditionCompliant, Status: corev1.ConditionFalse, LastTransitionTime: earlier},
+			},
+		},
+	}
+
+	setComplianceCondition(node, check.Summary{Fail: 1}, now)
+	assert.Equal(t, earlier, node.Status.Conditions[0].LastTransitionTime)
+	assert.Equal(t, now, node.Status.Conditions[0].LastHeartbeatTime)
+
+	setComplianceCondition(node, check.Summary{Pass: 1}, now)
+	assert.Equal(t, now, node.Status.Conditions[0].LastTransitionTime)
+	assert.Equal(t, corev1.ConditionTrue, node.Status.Conditions[0].Status)
+}
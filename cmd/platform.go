@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxConfidence is the confidence of a certain detection.
+const maxConfidence = 100
+
+// PlatformDetector detects whether kube-bench is running on a platform.
+type PlatformDetector interface {
+	// Name is the name of the platform, as used in the platforms section of config.yaml.
+	Name() string
+	// Detect returns how confident the detector is, from 0 to 100, that kube-bench runs on
+	// its platform, along with the evidence found.
+	Detect(facts *platformFacts) PlatformDetection
+}
+
+// PlatformDetection is the result of running a PlatformDetector.
+type PlatformDetection struct {
+	Platform   Platform `json:"platform"`
+	Confidence int      `json:"confidence"`
+	Evidence   []string `json:"evidence,omitempty"`
+}
+
+// platformDetectors are the detectors registered in code, run along with the ones
+// defined in the platforms section of config.yaml.
+var platformDetectors []PlatformDetector
+
+func registerPlatformDetector(d PlatformDetector) {
+	platformDetectors = append(platformDetectors, d)
+}
+
+// platformConfig is a platform as defined in the platforms section of config.yaml.
+type platformConfig struct {
+	Name   string         `mapstructure:"name"`
+	Detect []platformRule `mapstructure:"detect"`
+	// Benchmarks maps the platform version to a benchmark version.
+	Benchmarks map[string]string `mapstructure:"benchmarks"`
+	// DefaultBenchmark is used for platform versions not in Benchmarks.
+	DefaultBenchmark string `mapstructure:"default_benchmark"`
+	// LowerVersions looks for the closest lower minor version in Benchmarks before using DefaultBenchmark.
+	LowerVersions bool `mapstructure:"lower_versions"`
+}
+
+// platformRule is a single piece of evidence looked for to detect a platform.
+// Exactly one of Version, NodeLabel, NodeAnnotation, ProviderID, OSImage, File and Command is set.
+type platformRule struct {
+	Version        string `mapstructure:"version"`
+	NodeLabel      string `mapstructure:"node_label"`
+	NodeAnnotation string `mapstructure:"node_annotation"`
+	ProviderID     string `mapstructure:"provider_id"`
+	OSImage        string `mapstructure:"os_image"`
+	File           string `mapstructure:"file"`
+	Command        string `mapstructure:"command"`
+	Output         string `mapstructure:"output"`
+	Confidence     int    `mapstructure:"confidence"`
+	Exclude        bool   `mapstructure:"exclude"`
+}
+
+func (r platformRule) validate() error {
+	set := 0
+	for _, s := range []string{r.Version, r.NodeLabel, r.NodeAnnotation, r.ProviderID, r.OSImage, r.File, r.Command} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of version, node_label, node_annotation, provider_id, os_image, file and command must be set")
+	}
+	if r.Output != "" && r.Command == "" {
+		return fmt.Errorf("output can only be used with command")
+	}
+	for _, re := range []string{r.Version, r.OSImage, r.Output} {
+		if _, err := regexp.Compile(re); err != nil {
+			return fmt.Errorf("invalid regex %q: %v", re, err)
+		}
+	}
+	if !r.Exclude && (r.Confidence <= 0 || r.Confidence > maxConfidence) {
+		return fmt.Errorf("confidence must be between 1 and %d", maxConfidence)
+	}
+	return nil
+}
+
+// match reports whether the rule's evidence is found, describing it, and the platform version
+// the evidence gives, if any.
+func (r platformRule) match(facts *platformFacts) (bool, string, string) {
+	switch {
+	case r.Version != "":
+		gv := facts.serverVersion
+		if gv != "" && regexp.MustCompile(r.Version).MatchString(gv) {
+			return true, fmt.Sprintf("server version %q matches %q", gv, r.Version), ""
+		}
+	case r.NodeLabel != "":
+		if node := facts.nodeWithLabel(r.NodeLabel); node != nil {
+			return true, fmt.Sprintf("node %s has label %q", node.Name, r.NodeLabel), ""
+		}
+	case r.NodeAnnotation != "":
+		if node := facts.node(); node != nil {
+			if _, ok := node.Annotations[r.NodeAnnotation]; ok {
+				return true, fmt.Sprintf("node %s has annotation %q", node.Name, r.NodeAnnotation), ""
+			}
+		}
+	case r.ProviderID != "":
+		if node := facts.node(); node != nil && strings.HasPrefix(node.Spec.ProviderID, r.ProviderID) {
+			return true, fmt.Sprintf("node %s has provider ID %q", node.Name, node.Spec.ProviderID), ""
+		}
+	case r.OSImage != "":
+		if node := facts.node(); node != nil && regexp.MustCompile(r.OSImage).MatchString(node.Status.NodeInfo.OSImage) {
+			return true, fmt.Sprintf("node %s runs %q", node.Name, node.Status.NodeInfo.OSImage), ""
+		}
+	case r.File != "":
+		if _, err := facts.stat(r.File); err == nil {
+			return true, fmt.Sprintf("file %s exists", r.File), ""
+		}
+	case r.Command != "":
+		out, err := facts.output(r.Command)
+		if err != nil {
+			return false, "", ""
+		}
+		if r.Output == "" {
+			return true, fmt.Sprintf("`%s` succeeded", r.Command), ""
+		}
+		subs := regexp.MustCompile(r.Output).FindStringSubmatch(out)
+		if subs == nil {
+			return false, "", ""
+		}
+		for _, version := range subs[1:] {
+			if version != "" {
+				return true, fmt.Sprintf("`%s` reports version %s", r.Command, version), version
+			}
+		}
+		return true, fmt.Sprintf("`%s` output matches %q", r.Command, r.Output), ""
+	}
+	return false, "", ""
+}
+
+// ruleDetector detects a platform defined in config.yaml from its rules.
+type ruleDetector struct {
+	config platformConfig
+}
+
+func (d *ruleDetector) Name() string {
+	return d.config.Name
+}
+
+// Detect adds up the confidence of the rules that match, unless an exclude rule matches.
+func (d *ruleDetector) Detect(facts *platformFacts) PlatformDetection {
+	det := PlatformDetection{Platform: Platform{Name: d.config.Name}}
+	for _, r := range d.config.Detect {
+		ok, evidence, version := r.match(facts)
+		if !ok {
+			continue
+		}
+		if r.Exclude {
+			return PlatformDetection{Platform: Platform{Name: d.config.Name}, Evidence: []string{"excluded as " + evidence}}
+		}
+		det.Confidence += r.Confidence
+		det.Evidence = append(det.Evidence, evidence)
+		if det.Platform.Version == "" {
+			det.Platform.Version = version
+		}
+	}
+
+	if det.Confidence > maxConfidence {
+		det.Confidence = maxConfidence
+	}
+	if det.Confidence > 0 && det.Platform.Version == "" {
+		det.Platform.Version = facts.kubeMinorVersion()
+	}
+	return det
+}
+
+// loadPlatformConfigs reads and validates the platforms section of config.yaml.
+func loadPlatformConfigs(v *viper.Viper) ([]platformConfig, error) {
+	var platforms []platformConfig
+	if err := v.UnmarshalKey("platforms", &platforms); err != nil {
+		return nil, fmt.Errorf("failed to read 'platforms' section of config file: %v", err)
+	}
+
+	for _, p := range platforms {
+		if p.Name == "" {
+			return nil, fmt.Errorf("platform without a name in config file")
+		}
+		for i, r := range p.Detect {
+			if err := r.validate(); err != nil {
+				return nil, fmt.Errorf("platform %s, detect rule %d: %v", p.Name, i+1, err)
+			}
+		}
+	}
+	return platforms, nil
+}
+
+// getPlatformDetectors returns the detectors registered in code followed by those defined in config.yaml.
+func getPlatformDetectors(v *viper.Viper) ([]PlatformDetector, error) {
+	platforms, err := loadPlatformConfigs(v)
+	if err != nil {
+		return nil, err
+	}
+
+	detectors := append([]PlatformDetector{}, platformDetectors...)
+	for _, p := range platforms {
+		detectors = append(detectors, &ruleDetector{config: p})
+	}
+	return detectors, nil
+}
+
+// detectPlatforms runs every detector, returning their detections by decreasing confidence.
+// Detections with the same confidence keep the order of the detectors.
+func detectPlatforms(detectors []PlatformDetector, facts *platformFacts) []PlatformDetection {
+	detections := make([]PlatformDetection, 0, len(detectors))
+	for _, d := range detectors {
+		det := d.Detect(facts)
+		glog.V(2).Info(fmt.Sprintf("Platform %s detected with confidence %d: %v", d.Name(), det.Confidence, det.Evidence))
+		detections = append(detections, det)
+	}
+
+	sort.SliceStable(detections, func(i, j int) bool {
+		return detections[i].Confidence > detections[j].Confidence
+	})
+	return detections
+}
+
+// getPlatformDetections gathers the facts about the cluster, with kv the detected Kubernetes version
+// if any, and runs the platform detectors on them.
+func getPlatformDetections(v *viper.Viper, kv *KubeVersion) ([]PlatformDetection, error) {
+	detectors, err := getPlatformDetectors(v)
+	if err != nil {
+		return nil, err
+	}
+	return detectPlatforms(detectors, newPlatformFacts(kv)), nil
+}
+
+// getPlatformInfo returns the platform detected with the highest confidence,
+// or an empty Platform if none was detected.
+func getPlatformInfo(kv *KubeVersion) Platform {
+	detections, err := getPlatformDetections(viper.GetViper(), kv)
+	if err != nil {
+		glog.V(1).Info(fmt.Sprintf("Unable to detect platform: %v", err))
+		return Platform{}
+	}
+	return bestPlatform(detections)
+}
+
+// bestPlatform returns the platform of the first of detections, ordered by decreasing confidence,
+// or an empty Platform if it wasn't detected.
+func bestPlatform(detections []PlatformDetection) Platform {
+	if len(detections) == 0 || detections[0].Confidence == 0 {
+		return Platform{}
+	}
+	return detections[0].Platform
+}
+
+// getPlatformBenchmarkVersion maps the version of platform to a benchmark version using
+// the platforms section of config.yaml, returning an empty string if there is no mapping.
+func getPlatformBenchmarkVersion(platform Platform, v *viper.Viper) string {
+	glog.V(3).Infof("getPlatformBenchmarkVersion platform: %s", platform)
+
+	platforms, err := loadPlatformConfigs(v)
+	if err != nil {
+		glog.V(1).Info(err)
+		return ""
+	}
+
+	for _, p := range platforms {
+		if p.Name != platform.Name {
+			continue
+		}
+		if bv, ok := p.Benchmarks[platform.Version]; ok {
+			return bv
+		}
+		if p.LowerVersions {
+			for version := decrementVersion(platform.Version); !isEmpty(version); version = decrementVersion(version) {
+				if bv, ok := p.Benchmarks[version]; ok {
+					glog.V(1).Info(fmt.Sprintf("Using benchmark for %s %s with version %s", p.Name, version, platform.Version))
+					return bv
+				}
+			}
+		}
+		return p.DefaultBenchmark
+	}
+	return ""
+}
+
+// platformFacts are what the platform detectors look at, each read at most once per detection.
+type platformFacts struct {
+	// client is nil when kube-bench is not running in a cluster.
+	client        kubernetes.Interface
+	serverVersion string
+	runCommand    func(command string) (string, error)
+	stat          func(name string) (os.FileInfo, error)
+
+	nodeRead  bool
+	firstNode *corev1.Node
+	outputs   map[string]commandOutput
+}
+
+type commandOutput struct {
+	out string
+	err error
+}
+
+// newPlatformFacts gets a client for the cluster if kube-bench runs in one. kv is the Kubernetes
+// version detected by the caller, nil if it wasn't.
+func newPlatformFacts(kv *KubeVersion) *platformFacts {
+	facts := &platformFacts{runCommand: runPlatformCommand, stat: statFunc}
+	if k8sClient, err := getInClusterClient(); err == nil {
+		facts.client = k8sClient
+	}
+	if kv != nil {
+		facts.serverVersion = kv.GitVersion
+	}
+	return facts
+}
+
+// kubeMinorVersion returns the major and minor Kubernetes version, for example 1.29.
+func (f *platformFacts) kubeMinorVersion() string {
+	subs := regexp.MustCompile(`^v?(\d+\.\d+)`).FindStringSubmatch(f.serverVersion)
+	if subs == nil {
+		return ""
+	}
+	return subs[1]
+}
+
+// node returns the first node of the cluster.
+func (f *platformFacts) node() *corev1.Node {
+	if f.nodeRead || f.client == nil {
+		return f.firstNode
+	}
+	f.nodeRead = true
+
+	nodes, err := f.client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{Limit: 1})
+	if err != nil {
+		glog.V(2).Info(fmt.Sprintf("Failed to list nodes: %v", err))
+		return nil
+	}
+	if len(nodes.Items) > 0 {
+		f.firstNode = &nodes.Items[0]
+	}
+	return f.firstNode
+}
+
+// nodeWithLabel returns a node matching the label selector.
+func (f *platformFacts) nodeWithLabel(selector string) *corev1.Node {
+	if f.client == nil {
+		return nil
+	}
+	nodes, err := f.client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{Limit: 1, LabelSelector: selector})
+	if err != nil {
+		glog.V(2).Info(fmt.Sprintf("Failed to list nodes with label %s: %v", selector, err))
+		return nil
+	}
+	if len(nodes.Items) == 0 {
+		return nil
+	}
+	return &nodes.Items[0]
+}
+
+func (f *platformFacts) output(command string) (string, error) {
+	if f.outputs == nil {
+		f.outputs = make(map[string]commandOutput)
+	}
+	if o, ok := f.outputs[command]; ok {
+		return o.out, o.err
+	}
+	out, err := f.runCommand(command)
+	if err != nil {
+		glog.V(2).Info(fmt.Sprintf("Platform detection command %q failed: %v", command, err))
+	}
+	f.outputs[command] = commandOutput{out: out, err: err}
+	return out, err
+}
+
+// runPlatformCommand runs command if its program is on the path.
+func runPlatformCommand(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return "", err
+	}
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	return string(out), err
+}
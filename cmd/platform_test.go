@@ -0,0 +1,635 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDetectPlatformFromVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want Platform
+	}{
+		{
+			name: "eks",
+			s:    "v1.17.9-eks-4c6976",
+			want: Platform{Name: "eks", Version: "1.17"},
+		},
+		{
+			name: "gke",
+			s:    "v1.17.6-gke.1",
+			want: Platform{Name: "gke", Version: "1.17"},
+		},
+		{
+			name: "ack",
+			s:    "v1.18.8-aliyun.1",
+			want: Platform{Name: "aliyun", Version: "1.18"},
+		},
+		{
+			name: "unknown",
+			s:    "v1.17.6",
+			want: Platform{},
+		},
+		{
+			name: "empty string",
+			s:    "",
+			want: Platform{},
+		},
+		{
+			name: "k3s",
+			s:    "v1.27.6+k3s1",
+			want: Platform{Name: "k3s", Version: "1.27"},
+		},
+		{
+			name: "rke2",
+			s:    "v1.27.6+rke2r1",
+			want: Platform{Name: "rke2r", Version: "1.27"},
+		},
+		{
+			name: "tkgi",
+			s:    "v1.25.10+vmware.1",
+			want: Platform{Name: "vmware", Version: "1.25"},
+		},
+	}
+
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	detectors, err := getPlatformDetectors(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facts := &platformFacts{serverVersion: tt.s, runCommand: noPlatformCommand, stat: fakeFiles()}
+			assert.Equal(t, tt.want, bestPlatform(detectPlatforms(detectors, facts)))
+		})
+	}
+}
+
+func TestDetectPlatformFromNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodes    []corev1.Node
+		want     Platform
+		evidence []string
+	}{
+		{
+			name: "aks label",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "aks-1", Labels: map[string]string{"kubernetes.azure.com/cluster": "rg"}},
+				Spec:       corev1.NodeSpec{ProviderID: "azure:///subscriptions/1/vm-1"},
+			}},
+			want: Platform{Name: "aks", Version: "1.29"},
+			evidence: []string{
+				`node aks-1 has label "kubernetes.azure.com/cluster"`,
+				`node aks-1 has provider ID "azure:///subscriptions/1/vm-1"`,
+			},
+		},
+		{
+			name: "rke",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "rke-1", Annotations: map[string]string{"rke.cattle.io/internal-ip": "10.0.0.1"}},
+			}},
+			want:     Platform{Name: "rancher", Version: "1.29"},
+			evidence: []string{`node rke-1 has annotation "rke.cattle.io/internal-ip"`},
+		},
+		{
+			name: "rke with windows nodes",
+			nodes: []corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "rke-1", Annotations: map[string]string{"rke.cattle.io/internal-ip": "10.0.0.1"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "win-1", Labels: map[string]string{"kubernetes.io/os": "windows"}}},
+			},
+			want: Platform{},
+		},
+	}
+
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	detectors, err := getPlatformDetectors(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for i := range tt.nodes {
+				_ = client.Tracker().Add(&tt.nodes[i])
+			}
+			facts := &platformFacts{client: client, serverVersion: "v1.29.4", runCommand: noPlatformCommand, stat: fakeFiles()}
+			detections := detectPlatforms(detectors, facts)
+			assert.Equal(t, tt.want, bestPlatform(detections))
+			if tt.evidence != nil {
+				assert.Equal(t, tt.evidence, detections[0].Evidence)
+			}
+		})
+	}
+}
+
+func TestDetectDistributions(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodes     []corev1.Node
+		files     []string
+		want      Platform
+		benchmark string
+	}{
+		{
+			name: "talos",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "talos-cp-1", Annotations: map[string]string{"kubeadm.alpha.kubernetes.io/cri-socket": "unix:///run/containerd/containerd.sock"}},
+				Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OSImage: "Talos (v1.7.5)"}},
+			}},
+			want:      Platform{Name: "talos", Version: "1.29"},
+			benchmark: "",
+		},
+		{
+			name:      "talos from static pod manifest",
+			files:     []string{"/etc/kubernetes/manifests/talos-kube-apiserver.yaml"},
+			want:      Platform{Name: "talos", Version: "1.29"},
+			benchmark: "",
+		},
+		{
+			name:      "microk8s from args file",
+			files:     []string{"/var/snap/microk8s/current/args/kubelet"},
+			want:      Platform{Name: "microk8s", Version: "1.29"},
+			benchmark: "cis-1.24-microk8s",
+		},
+		{
+			name: "microk8s from node label",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "mk8s", Labels: map[string]string{"microk8s.io/cluster": "true"}},
+			}},
+			want:      Platform{Name: "microk8s", Version: "1.29"},
+			benchmark: "cis-1.24-microk8s",
+		},
+		{
+			name: "kind",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "kind-control-plane", Annotations: map[string]string{"kubeadm.alpha.kubernetes.io/cri-socket": "unix:///run/containerd/containerd.sock"}},
+				Spec:       corev1.NodeSpec{ProviderID: "kind://docker/kind/kind-control-plane"},
+			}},
+			files:     []string{"/var/lib/kubelet/kubeadm-flags.env"},
+			want:      Platform{Name: "kind", Version: "1.29"},
+			benchmark: "",
+		},
+		{
+			name: "kubeadm",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "cp-1", Annotations: map[string]string{"kubeadm.alpha.kubernetes.io/cri-socket": "unix:///var/run/crio/crio.sock"}},
+			}},
+			files:     []string{"/var/lib/kubelet/kubeadm-flags.env"},
+			want:      Platform{Name: "kubeadm", Version: "1.29"},
+			benchmark: "",
+		},
+		{
+			name: "oke",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "10.0.10.2", Labels: map[string]string{"oke.oraclecloud.com/tenant_agent.version": "1.50.0"}},
+				Spec:       corev1.NodeSpec{ProviderID: "ocid1.instance.oc1.iad.abc"},
+			}},
+			want:      Platform{Name: "oke", Version: "1.29"},
+			benchmark: "",
+		},
+		{
+			name: "doks",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "pool-1-abc", Labels: map[string]string{"doks.digitalocean.com/node-id": "1234"}},
+				Spec:       corev1.NodeSpec{ProviderID: "digitalocean://1234"},
+			}},
+			want:      Platform{Name: "doks", Version: "1.29"},
+			benchmark: "",
+		},
+		{
+			name: "digitalocean droplet without doks",
+			nodes: []corev1.Node{{
+				ObjectMeta: metav1.ObjectMeta{Name: "droplet"},
+				Spec:       corev1.NodeSpec{ProviderID: "digitalocean://1234"},
+			}},
+			want:      Platform{Name: "doks", Version: "1.29"},
+			benchmark: "",
+		},
+	}
+
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	detectors, err := getPlatformDetectors(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for i := range tt.nodes {
+				_ = client.Tracker().Add(&tt.nodes[i])
+			}
+			facts := &platformFacts{client: client, serverVersion: "v1.29.6", runCommand: noPlatformCommand, stat: fakeFiles(tt.files...)}
+			got := bestPlatform(detectPlatforms(detectors, facts))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.benchmark, getPlatformBenchmarkVersion(got, v))
+		})
+	}
+}
+
+func TestDetectPlatformFromCommand(t *testing.T) {
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	detectors, err := getPlatformDetectors(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	calls := 0
+	facts := &platformFacts{serverVersion: "v1.26.3+b404935", stat: fakeFiles(), runCommand: func(command string) (string, error) {
+		calls++
+		assert.Equal(t, "oc version", command)
+		return "Client Version: 4.13.0\nKustomize Version: v4.5.7\n", nil
+	}}
+	detections := detectPlatforms(detectors, facts)
+	assert.Equal(t, Platform{Name: "ocp", Version: "4.13"}, bestPlatform(detections))
+	assert.Equal(t, 100, detections[0].Confidence)
+	assert.Equal(t, []string{"`oc version` reports version 4.13"}, detections[0].Evidence)
+	assert.Equal(t, 1, calls)
+}
+
+func TestLoadPlatformConfigsInvalid(t *testing.T) {
+	cases := []struct {
+		name     string
+		platform map[string]interface{}
+		err      string
+	}{
+		{
+			name:     "no name",
+			platform: map[string]interface{}{"detect": []interface{}{map[string]interface{}{"version": "x", "confidence": 50}}},
+			err:      "platform without a name",
+		},
+		{
+			name:     "two evidence kinds",
+			platform: map[string]interface{}{"name": "p", "detect": []interface{}{map[string]interface{}{"version": "x", "node_label": "y", "confidence": 50}}},
+			err:      "platform p, detect rule 1: exactly one of",
+		},
+		{
+			name:     "invalid regex",
+			platform: map[string]interface{}{"name": "p", "detect": []interface{}{map[string]interface{}{"version": "(", "confidence": 50}}},
+			err:      "invalid regex",
+		},
+		{
+			name:     "no confidence",
+			platform: map[string]interface{}{"name": "p", "detect": []interface{}{map[string]interface{}{"version": "x"}}},
+			err:      "confidence must be between 1 and 100",
+		},
+		{
+			name:     "output without command",
+			platform: map[string]interface{}{"name": "p", "detect": []interface{}{map[string]interface{}{"version": "x", "output": "y", "confidence": 50}}},
+			err:      "output can only be used with command",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v := viper.New()
+			v.Set("platforms", []interface{}{c.platform})
+			_, err := loadPlatformConfigs(v)
+			assert.ErrorContains(t, err, c.err)
+		})
+	}
+}
+
+func TestPrintPlatformDetections(t *testing.T) {
+	detections := []PlatformDetection{
+		{Platform: Platform{Name: "eks", Version: "1.29"}, Confidence: 90, Evidence: []string{`server version "v1.29.3-eks-adc7111" matches "[-+]eks\\b"`}},
+		{Platform: Platform{Name: "rancher"}, Evidence: []string{`excluded as node win-1 has label "kubernetes.io/os=windows"`}},
+		{Platform: Platform{Name: "gke"}},
+		{Platform: Platform{Name: "k3s"}},
+	}
+
+	var buf bytes.Buffer
+	printPlatformDetections(&buf, detections, "eks-1.7.0")
+	assert.Equal(t, `Platform: eks 1.29 (confidence 90)
+Benchmark: eks-1.7.0
+
+eks: confidence 90
+	 server version "v1.29.3-eks-adc7111" matches "[-+]eks\\b"
+rancher: confidence 0
+	 excluded as node win-1 has label "kubernetes.io/os=windows"
+No evidence found for: gke, k3s
+`, buf.String())
+
+	buf.Reset()
+	printPlatformDetections(&buf, detections[2:], "cis-1.11")
+	assert.Contains(t, buf.String(), "Platform: none detected\nBenchmark: cis-1.11\n")
+}
+
+func noPlatformCommand(string) (string, error) {
+	return "", assert.AnError
+}
+
+// fakeFiles returns a stat function finding only files.
+func fakeFiles(files ...string) func(string) (os.FileInfo, error) {
+	return func(name string) (os.FileInfo, error) {
+		for _, f := range files {
+			if f == name {
+				return nil, nil
+			}
+		}
+		return nil, os.ErrNotExist
+	}
+}
+
+func Test_getPlatformBenchmarkVersion(t *testing.T) {
+	type args struct {
+		platform Platform
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "eks 1.31",
+			args: args{
+				platform: Platform{Name: "eks", Version: "1.31"},
+			},
+			want: "eks-1.7.0",
+		},
+		{
+			name: "eks 1.29",
+			args: args{
+				platform: Platform{Name: "eks", Version: "1.29"},
+			},
+			want: "eks-1.7.0",
+		},
+		{
+			name: "eks 1.30",
+			args: args{
+				platform: Platform{Name: "eks", Version: "1.30"},
+			},
+			want: "eks-1.7.0",
+		},
+		{
+			name: "eks 1.32",
+			args: args{
+				platform: Platform{Name: "eks", Version: "1.32"},
+			},
+			want: "eks-1.8.0",
+		},
+		{
+			name: "eks 1.24",
+			args: args{
+				platform: Platform{Name: "eks", Version: "1.24"},
+			},
+			want: "eks-1.5.0",
+		},
+		{
+			name: "gke 1.19",
+			args: args{
+				platform: Platform{Name: "gke", Version: "1.19"},
+			},
+			want: "gke-1.0",
+		},
+		{
+			name: "gke 1.20",
+			args: args{
+				platform: Platform{Name: "gke", Version: "1.20"},
+			},
+			want: "gke-1.2.0",
+		},
+		{
+			name: "gke 1.22",
+			args: args{
+				platform: Platform{Name: "gke", Version: "1.22"},
+			},
+			want: "gke-1.2.0",
+		},
+		{
+			name: "gke 1.28",
+			args: args{
+				platform: Platform{Name: "gke", Version: "1.28"},
+			},
+			want: "gke-1.6.0",
+		},
+		{
+			name: "gke 1.30",
+			args: args{
+				platform: Platform{Name: "gke", Version: "1.30"},
+			},
+			want: "gke-1.8.0",
+		},
+		{
+			name: "gke 1.33",
+			args: args{
+				platform: Platform{Name: "gke", Version: "1.33"},
+			},
+			want: "gke-1.9.0",
+		},
+		{
+			name: "aliyun",
+			args: args{
+				platform: Platform{Name: "aliyun"},
+			},
+			want: "ack-1.0",
+		},
+		{
+			name: "unknown",
+			args: args{
+				platform: Platform{Name: "rh"},
+			},
+			want: "",
+		},
+		{
+			name: "empty",
+			args: args{
+				platform: Platform{},
+			},
+			want: "",
+		},
+		{
+			name: "openshift3",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "3.10"},
+			},
+			want: "rh-0.7",
+		},
+		{
+			name: "openshift4",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "4.11"},
+			},
+			want: "rh-1.4",
+		},
+		{
+			name: "openshift4",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "4.13"},
+			},
+			want: "rh-1.8",
+		},
+		{
+			name: "openshift4",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "4.1"},
+			},
+			want: "rh-1.0",
+		},
+		{
+			name: "k3s 1.25",
+			args: args{
+				platform: Platform{Name: "k3s", Version: "1.25"},
+			},
+			want: "k3s-cis-1.7",
+		},
+		{
+			name: "k3s 1.26",
+			args: args{
+				platform: Platform{Name: "k3s", Version: "1.26"},
+			},
+			want: "k3s-cis-1.8",
+		},
+		{
+			name: "k3s 1.27",
+			args: args{
+				platform: Platform{Name: "k3s", Version: "1.27"},
+			},
+			want: "k3s-cis-1.9",
+		},
+		{
+			name: "k3s 1.28",
+			args: args{
+				platform: Platform{Name: "k3s", Version: "1.28"},
+			},
+			want: "k3s-cis-1.9",
+		},
+		{
+			name: "k3s 1.29",
+			args: args{
+				platform: Platform{Name: "k3s", Version: "1.29"},
+			},
+			want: "k3s-cis-1.9",
+		},
+		{
+			name: "rancher1",
+			args: args{
+				platform: Platform{Name: "rancher", Version: "1.27"},
+			},
+			want: "rke-cis-1.7",
+		},
+		{
+			name: "rke2",
+			args: args{
+				platform: Platform{Name: "rke2r", Version: "1.25"},
+			},
+			want: "rke2-cis-1.7",
+		},
+		{
+			name: "rke2",
+			args: args{
+				platform: Platform{Name: "rke2r", Version: "1.26"},
+			},
+			want: "rke2-cis-1.8",
+		},
+		{
+			name: "aks",
+			args: args{
+				platform: Platform{Name: "aks", Version: "1.29"},
+			},
+			want: "aks-1.7",
+		},
+		{
+			name: "aks",
+			args: args{
+				platform: Platform{Name: "aks", Version: "1.32"},
+			},
+			want: "aks-1.8",
+		},
+		{
+			name: "ocp 3.11",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "3.11"},
+			},
+			want: "rh-0.7",
+		},
+		{
+			name: "ocp 4.6",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "4.6"},
+			},
+			want: "rh-1.0",
+		},
+		{
+			name: "ocp 2.9",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "2.9"},
+			},
+			want: "",
+		},
+		{
+			name: "ocp invalid",
+			args: args{
+				platform: Platform{Name: "ocp", Version: "invalid"},
+			},
+			want: "",
+		},
+	}
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getPlatformBenchmarkVersion(tt.args.platform, v); got != tt.want {
+				t.Errorf("getPlatformBenchmarkVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type staticDetector struct {
+	detection PlatformDetection
+}
+
+func (d staticDetector) Name() string {
+	return d.detection.Platform.Name
+}
+
+func (d staticDetector) Detect(*platformFacts) PlatformDetection {
+	return d.detection
+}
+
+func TestRegisterPlatformDetector(t *testing.T) {
+	old := platformDetectors
+	defer func() { platformDetectors = old }()
+
+	registerPlatformDetector(staticDetector{PlatformDetection{Platform: Platform{Name: "custom", Version: "1.0"}, Confidence: 95}})
+
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	detectors, err := getPlatformDetectors(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "custom", detectors[0].Name())
+
+	facts := &platformFacts{serverVersion: "v1.29.3-eks-adc7111", runCommand: noPlatformCommand, stat: fakeFiles()}
+	detections := detectPlatforms(detectors, facts)
+	assert.Equal(t, Platform{Name: "custom", Version: "1.0"}, bestPlatform(detections))
+	assert.Equal(t, "eks", detections[1].Platform.Name)
+}
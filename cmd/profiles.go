@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// scanProfile is a named set of run options, defined in the profiles section of config.yaml
+// or in a file given with --profile.
+type scanProfile struct {
+	Name        string         `mapstructure:"name" yaml:"name"`
+	Description string         `mapstructure:"description" yaml:"description"`
+	Benchmark   string         `mapstructure:"benchmark" yaml:"benchmark"`
+	Version     string         `mapstructure:"version" yaml:"version"`
+	Targets     []string       `mapstructure:"targets" yaml:"targets"`
+	Checks      []string       `mapstructure:"checks" yaml:"checks"`
+	Groups      []string       `mapstructure:"groups" yaml:"groups"`
+	Filter      string         `mapstructure:"filter" yaml:"filter"`
+	Tags        []string       `mapstructure:"tags" yaml:"tags"`
+	ExcludeTags []string       `mapstructure:"exclude_tags" yaml:"exclude_tags"`
+	Skip        []string       `mapstructure:"skip" yaml:"skip"`
+	SkipFiles   []string       `mapstructure:"skip_files" yaml:"skip_files"`
+	Scored      *bool          `mapstructure:"scored" yaml:"scored"`
+	Unscored    *bool          `mapstructure:"unscored" yaml:"unscored"`
+	Outputs     profileOutputs `mapstructure:"outputs" yaml:"outputs"`
+	ExitCode    *int           `mapstructure:"exit_code" yaml:"exit_code"`
+}
+
+// profileOutputs are the output sinks of a profile.
+type profileOutputs struct {
+	JSON              bool   `mapstructure:"json" yaml:"json"`
+	JUnit             bool   `mapstructure:"junit" yaml:"junit"`
+	OCSF              bool   `mapstructure:"ocsf" yaml:"ocsf"`
+	PgSQL             bool   `mapstructure:"pgsql" yaml:"pgsql"`
+	ASFF              bool   `mapstructure:"asff" yaml:"asff"`
+	PublishNodeStatus bool   `mapstructure:"publish_node_status" yaml:"publish_node_status"`
+	OutputFile        string `mapstructure:"outputfile" yaml:"outputfile"`
+	Webhook           string `mapstructure:"webhook" yaml:"webhook"`
+}
+
+var profileName string
+
+func init() {
+	runCmd.Flags().StringVar(&profileName, "profile", "", "Name of a profile in config.yaml, or a profile file, setting the flags not given on the command line")
+	profilesCmd.AddCommand(profilesListCmd)
+	profilesCmd.AddCommand(profilesValidateCmd)
+	RootCmd.AddCommand(profilesCmd)
+}
+
+// profilesCmd represents the profiles command
+var profilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List and validate the scan profiles",
+	Long:  `List and validate the scan profiles defined in the profiles section of config.yaml, or in profile files.`,
+}
+
+var profilesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles defined in config.yaml",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := loadProfiles(viper.GetViper())
+		if err != nil {
+			exitWithError(err)
+		}
+		printProfiles(os.Stdout, profiles)
+	},
+}
+
+var profilesValidateCmd = &cobra.Command{
+	Use:   "validate [profile or file]...",
+	Short: "Validate the given profiles, or all profiles defined in config.yaml",
+	Run: func(cmd *cobra.Command, args []string) {
+		var profiles []scanProfile
+		if len(args) == 0 {
+			var err error
+			if profiles, err = loadProfiles(viper.GetViper()); err != nil {
+				exitWithError(err)
+			}
+		}
+		for _, arg := range args {
+			p, err := getProfile(arg, viper.GetViper())
+			if err != nil {
+				exitWithError(err)
+			}
+			profiles = append(profiles, p)
+		}
+
+		invalid := false
+		for _, p := range profiles {
+			if err := validateProfile(p, cfgDir, viper.GetViper()); err != nil {
+				invalid = true
+				fmt.Printf("%s: invalid\n", p.Name)
+				for _, e := range strings.Split(err.Error(), "\n") {
+					fmt.Printf("\t %s\n", e)
+				}
+				continue
+			}
+			fmt.Printf("%s: valid\n", p.Name)
+		}
+		if invalid {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadProfiles reads the profiles section of config.yaml.
+func loadProfiles(v *viper.Viper) ([]scanProfile, error) {
+	var profiles []scanProfile
+	err := v.UnmarshalKey("profiles", &profiles, func(c *mapstructure.DecoderConfig) { c.ErrorUnused = true })
+	if err != nil {
+		return nil, fmt.Errorf("failed to read 'profiles' section of config file: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, p := range profiles {
+		if p.Name == "" {
+			return nil, fmt.Errorf("profile without a name in config file")
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("profile %s is defined twice in config file", p.Name)
+		}
+		names[p.Name] = true
+	}
+	return profiles, nil
+}
+
+// loadProfileFile reads a profile file. The profile is named after the file if it has no name.
+func loadProfileFile(path string) (scanProfile, error) {
+	var p scanProfile
+	in, err := os.ReadFile(path)
+	if err != nil {
+		return p, fmt.Errorf("error reading profile file %s: %v", path, err)
+	}
+	if err := yaml.UnmarshalStrict(in, &p); err != nil {
+		return p, fmt.Errorf("error parsing profile file %s: %v", path, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return p, nil
+}
+
+// getProfile returns the profile named name in config.yaml or, if name is the path of a file, the profile it defines.
+func getProfile(name string, v *viper.Viper) (scanProfile, error) {
+	if _, err := os.Stat(name); err == nil {
+		return loadProfileFile(name)
+	}
+
+	profiles, err := loadProfiles(v)
+	if err != nil {
+		return scanProfile{}, err
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return scanProfile{}, fmt.Errorf("profile %s not found in config file, and no such file", name)
+}
+
+// validateProfile reports every problem of profile p, for the benchmarks in dir.
+func validateProfile(p scanProfile, dir string, v *viper.Viper) error {
+	var errs []error
+	if p.Benchmark != "" && p.Version != "" {
+		errs = append(errs, fmt.Errorf("benchmark and version can't be used together"))
+	}
+	if len(p.Checks) > 0 && len(p.Groups) > 0 {
+		errs = append(errs, fmt.Errorf("checks and groups can't be used together"))
+	}
+	if p.Filter != "" {
+		if _, err := check.CompileFilter(p.Filter); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if p.Scored != nil && p.Unscored != nil && !*p.Scored && !*p.Unscored {
+		errs = append(errs, fmt.Errorf("scored and unscored are both false, no check would run"))
+	}
+
+	if p.Benchmark != "" {
+		if info, err := os.Stat(filepath.Join(dir, p.Benchmark)); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("benchmark %s not found in %s", p.Benchmark, dir))
+		} else if len(p.Targets) > 0 {
+			valid, err := validTargets(p.Benchmark, p.Targets, v)
+			if err != nil {
+				errs = append(errs, err)
+			} else if !valid {
+				errs = append(errs, fmt.Errorf("targets %s are not configured for benchmark %s", strings.Join(p.Targets, ","), p.Benchmark))
+			}
+		}
+	} else if len(p.Targets) > 0 {
+		known := knownTargets(v)
+		for _, t := range p.Targets {
+			if !known[t] {
+				errs = append(errs, fmt.Errorf("target %s isn't configured for any benchmark", t))
+			}
+		}
+	}
+
+	for _, f := range p.SkipFiles {
+		if _, err := readSkipFile(f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	o := p.Outputs
+	if o.OutputFile != "" && !o.JSON && !o.JUnit && !o.OCSF {
+		errs = append(errs, fmt.Errorf("outputs: outputfile needs json, junit or ocsf"))
+	}
+	if p.ExitCode != nil && (*p.ExitCode < 0 || *p.ExitCode > 255) {
+		errs = append(errs, fmt.Errorf("exit_code %d isn't between 0 and 255", *p.ExitCode))
+	}
+	return errors.Join(errs...)
+}
+
+// knownTargets returns the targets of all benchmarks in the target_mapping section of config.yaml.
+func knownTargets(v *viper.Viper) map[string]bool {
+	known := make(map[string]bool)
+	mapping, err := loadTargetMapping(v)
+	if err != nil {
+		return known
+	}
+	for _, targets := range mapping {
+		for _, t := range targets {
+			known[strings.ToLower(t)] = true
+		}
+	}
+	return known
+}
+
+// readSkipFile reads the IDs of the checks and groups to skip from a file, separated by
+// commas or new lines. Lines starting with # are comments.
+func readSkipFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening skip file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, id := range strings.Split(line, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading skip file %s: %v", path, err)
+	}
+	return ids, nil
+}
+
+// profileFlags returns the values of the flags set by profile p.
+func profileFlags(p scanProfile) (map[string]string, error) {
+	flags := make(map[string]string)
+	setString := func(name, value string) {
+		if value != "" {
+			flags[name] = value
+		}
+	}
+	setBool := func(name string, value bool) {
+		if value {
+			flags[name] = "true"
+		}
+	}
+
+	setString("benchmark", p.Benchmark)
+	setString("version", p.Version)
+	setString("targets", strings.Join(p.Targets, ","))
+	setString("check", strings.Join(p.Checks, ","))
+	setString("group", strings.Join(p.Groups, ","))
+	setString("filter", p.Filter)
+	setString("tags", strings.Join(p.Tags, ","))
+	setString("exclude-tags", strings.Join(p.ExcludeTags, ","))
+
+	skip := append([]string{}, p.Skip...)
+	for _, f := range p.SkipFiles {
+		ids, err := readSkipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		skip = append(skip, ids...)
+	}
+	setString("skip", strings.Join(skip, ","))
+
+	if p.Scored != nil {
+		flags["scored"] = strconv.FormatBool(*p.Scored)
+	}
+	if p.Unscored != nil {
+		flags["unscored"] = strconv.FormatBool(*p.Unscored)
+	}
+
+	setBool("json", p.Outputs.JSON)
+	setBool("junit", p.Outputs.JUnit)
+	setBool("ocsf", p.Outputs.OCSF)
+	setBool("pgsql", p.Outputs.PgSQL)
+	setBool("asff", p.Outputs.ASFF)
+	setBool("publish-node-status", p.Outputs.PublishNodeStatus)
+	setString("outputfile", p.Outputs.OutputFile)
+	setString("webhook", p.Outputs.Webhook)
+
+	if p.ExitCode != nil {
+		flags["exit-code"] = strconv.Itoa(*p.ExitCode)
+	}
+	return flags, nil
+}
+
+// applyProfile sets the flags of cmd set by profile p that weren't given on the command line.
+func applyProfile(cmd *cobra.Command, p scanProfile) error {
+	values, err := profileFlags(p)
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		if cmd.Flags().Changed(name) {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("profile %s: invalid %s %q: %v", p.Name, name, value, err)
+		}
+	}
+	return nil
+}
+
+// printProfiles prints the profiles and what they set.
+func printProfiles(w io.Writer, profiles []scanProfile) {
+	if len(profiles) == 0 {
+		fmt.Fprintln(w, "No profiles defined")
+		return
+	}
+	for _, p := range profiles {
+		if p.Description != "" {
+			fmt.Fprintf(w, "%s: %s\n", p.Name, p.Description)
+		} else {
+			fmt.Fprintln(w, p.Name)
+		}
+		values, err := profileFlags(p)
+		if err != nil {
+			fmt.Fprintf(w, "\t %v\n", err)
+			continue
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "\t --%s=%s\n", name, values[name])
+		}
+	}
+}
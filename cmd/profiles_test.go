@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	profiles, err := loadProfiles(v)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, profiles)
+	for _, p := range profiles {
+		assert.NoError(t, validateProfile(p, "../cfg", v), "profile %s", p.Name)
+	}
+
+	testCases := []struct {
+		name   string
+		config string
+		expErr string
+	}{
+		{name: "no profiles", config: "version_mapping: {}"},
+		{name: "without a name", config: "profiles:\n- benchmark: cis-1.9", expErr: "profile without a name"},
+		{name: "defined twice", config: "profiles:\n- name: a\n- name: a", expErr: "profile a is defined twice"},
+		{name: "unknown field", config: "profiles:\n- name: a\n  targts: [master]", expErr: "targts"},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			cv := viper.New()
+			cv.SetConfigType("yaml")
+			assert.NoError(t, cv.ReadConfig(bytes.NewBufferString(c.config)))
+			_, err := loadProfiles(cv)
+			if c.expErr != "" {
+				assert.ErrorContains(t, err, c.expErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prod-node.yaml")
+	err = os.WriteFile(path, []byte("benchmark: cis-1.9\ntargets: [node]\nexit_code: 2\n"), 0o600)
+	assert.NoError(t, err)
+
+	p, err := getProfile(path, v)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-node", p.Name)
+	assert.Equal(t, "cis-1.9", p.Benchmark)
+	assert.Equal(t, 2, *p.ExitCode)
+
+	p, err = getProfile("ci-node", v)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node"}, p.Targets)
+
+	_, err = getProfile("nonexistent", v)
+	assert.ErrorContains(t, err, "profile nonexistent not found")
+
+	bad := filepath.Join(dir, "bad.yaml")
+	err = os.WriteFile(bad, []byte("benchmark: cis-1.9\nexit-code: 2\n"), 0o600)
+	assert.NoError(t, err)
+	_, err = getProfile(bad, v)
+	assert.ErrorContains(t, err, "error parsing profile file")
+}
+
+func TestValidateProfile(t *testing.T) {
+	v, err := loadConfigForTest()
+	if err != nil {
+		t.Fatalf("Unable to load config file %v", err)
+	}
+	dir := t.TempDir()
+	skipFile := filepath.Join(dir, "skip.txt")
+	assert.NoError(t, os.WriteFile(skipFile, []byte("1.1.1"), 0o600))
+
+	yes, no := true, false
+	code := 256
+	testCases := []struct {
+		name    string
+		profile scanProfile
+		expErrs []string
+	}{
+		{
+			name:    "valid",
+			profile: scanProfile{Name: "p", Benchmark: "cis-1.9", Targets: []string{"master", "node"}, SkipFiles: []string{skipFile}, Outputs: profileOutputs{JSON: true, OutputFile: "out.json"}},
+		},
+		{
+			name:    "targets without benchmark",
+			profile: scanProfile{Name: "p", Targets: []string{"node", "managedservices"}},
+		},
+		{
+			name: "everything wrong",
+			profile: scanProfile{
+				Name: "p", Benchmark: "cis-0.1", Version: "1.29", Checks: []string{"1.1.1"}, Groups: []string{"1.1"},
+				Filter: "group in 1.2", Scored: &no, Unscored: &no, SkipFiles: []string{filepath.Join(dir, "missing.txt")},
+				Outputs: profileOutputs{OutputFile: "out.json"}, ExitCode: &code,
+			},
+			expErrs: []string{
+				"benchmark and version can't be used together",
+				"checks and groups can't be used together",
+				`filter: expected "(" after in`,
+				"no check would run",
+				"benchmark cis-0.1 not found",
+				"error opening skip file",
+				"outputfile needs json, junit or ocsf",
+				"exit_code 256 isn't between 0 and 255",
+			},
+		},
+		{
+			name:    "targets not configured for the benchmark",
+			profile: scanProfile{Name: "p", Benchmark: "cis-1.9", Targets: []string{"managedservices"}, Scored: &yes},
+			expErrs: []string{"targets managedservices are not configured for benchmark cis-1.9"},
+		},
+		{
+			name:    "unknown target",
+			profile: scanProfile{Name: "p", Targets: []string{"nodes"}},
+			expErrs: []string{"target nodes isn't configured for any benchmark"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateProfile(c.profile, "../cfg", v)
+			if len(c.expErrs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, e := range c.expErrs {
+				assert.ErrorContains(t, err, e)
+			}
+		})
+	}
+}
+
+func TestReadSkipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skip.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# waived until 2027\n1.1.1, 1.1.2\n\n  apiserver-profiling\n"), 0o600))
+
+	ids, err := readSkipFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.1.1", "1.1.2", "apiserver-profiling"}, ids)
+}
+
+func TestApplyProfile(t *testing.T) {
+	skipFile := filepath.Join(t.TempDir(), "skip.txt")
+	assert.NoError(t, os.WriteFile(skipFile, []byte("1.2.3\n"), 0o600))
+
+	var (
+		benchmark, skip, outputfile string
+		tags, excludeTags           string
+		targets                     []string
+		scored, unscored, json      bool
+		exit                        int
+	)
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&benchmark, "benchmark", "", "")
+	cmd.Flags().StringSliceVar(&targets, "targets", nil, "")
+	cmd.Flags().StringVar(&skip, "skip", "", "")
+	cmd.Flags().StringVar(&tags, "tags", "", "")
+	cmd.Flags().StringVar(&excludeTags, "exclude-tags", "", "")
+	cmd.Flags().BoolVar(&scored, "scored", true, "")
+	cmd.Flags().BoolVar(&unscored, "unscored", true, "")
+	cmd.Flags().BoolVar(&json, "json", false, "")
+	cmd.Flags().StringVar(&outputfile, "outputfile", "", "")
+	cmd.Flags().IntVar(&exit, "exit-code", 0, "")
+	assert.NoError(t, cmd.Flags().Parse([]string{"--benchmark", "cis-1.8"}))
+
+	no, code := false, 2
+	p := scanProfile{
+		Name:      "p",
+		Benchmark: "cis-1.9",
+		Targets:   []string{"master", "etcd"},
+		Tags:      []string{"tls", "audit-logging"},
+		Skip:      []string{"1.1.1"},
+		SkipFiles: []string{skipFile},
+		Unscored:  &no,
+		Outputs:   profileOutputs{JSON: true, OutputFile: "out.json"},
+		ExitCode:  &code,
+	}
+	assert.NoError(t, applyProfile(cmd, p))
+
+	assert.Equal(t, "cis-1.8", benchmark, "flags given on the command line take precedence")
+	assert.Equal(t, []string{"master", "etcd"}, targets)
+	assert.Equal(t, "1.1.1,1.2.3", skip)
+	assert.Equal(t, "tls,audit-logging", tags)
+	assert.Empty(t, excludeTags)
+	assert.True(t, scored)
+	assert.False(t, unscored)
+	assert.True(t, json)
+	assert.Equal(t, "out.json", outputfile)
+	assert.Equal(t, 2, exit)
+
+	assert.ErrorContains(t, applyProfile(cmd, scanProfile{Name: "p", Checks: []string{"1.1.1"}}), "no such flag")
+}
+
+func TestPrintProfiles(t *testing.T) {
+	var out bytes.Buffer
+	printProfiles(&out, nil)
+	assert.Equal(t, "No profiles defined\n", out.String())
+
+	out.Reset()
+	code := 1
+	printProfiles(&out, []scanProfile{{Name: "prod", Description: "Production", Benchmark: "cis-1.9", ExitCode: &code}})
+	assert.Equal(t, "prod: Production\n\t --benchmark=cis-1.9\n\t --exit-code=1\n", out.String())
+}
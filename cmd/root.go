@@ -18,6 +18,7 @@ import (
 	goflag "flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aquasecurity/kube-bench/check"
 	"github.com/golang/glog"
@@ -26,24 +27,38 @@ import (
 )
 
 type FilterOpts struct {
-	CheckList string
-	GroupList string
-	Scored    bool
-	Unscored  bool
+	CheckList   string
+	GroupList   string
+	Scored      bool
+	Unscored    bool
+	Expression  string
+	Tags        string
+	ExcludeTags string
 }
 
 var (
 	envVarsPrefix        = "KUBE_BENCH"
 	defaultKubeVersion   = "1.18"
 	kubeVersion          string
+	strictVersion        bool
 	detecetedKubeVersion string
+	kubeVersionSource    string
 	benchmarkVersion     string
 	cfgFile              string
 	cfgDir               = "./cfg/"
 	jsonFmt              bool
 	junitFmt             bool
+	ocsfFmt              bool
 	pgSQL                bool
 	aSFF                 bool
+	aSFFResolve          bool
+	publishNodeResults   bool
+	webhookURL           string
+	webhookHeaders       []string
+	webhookEvents        bool
+	webhookBatchSize     int
+	webhookRetries       int
+	webhookTimeout       time.Duration
 	masterFile           = "master.yaml"
 	nodeFile             = "node.yaml"
 	etcdFile             = "etcd.yaml"
@@ -59,6 +74,13 @@ var (
 	filterOpts           FilterOpts
 	includeTestOutput    bool
 	outputFile           string
+	logFormat            string
+	traceFile            string
+	kubeletConfigzAudit  bool
+	criEndpoint          string
+	aliasesFile          string
+	attestationsFile     string
+	signKeyFile          string
 	configFileError      error
 	controlsCollection   []*check.Controls
 )
@@ -69,7 +91,7 @@ var RootCmd = &cobra.Command{
 	Short: "Run CIS Benchmarks checks against a Kubernetes deployment",
 	Long:  `This tool runs the CIS Kubernetes Benchmark (https://www.cisecurity.org/benchmark/kubernetes/)`,
 	Run: func(cmd *cobra.Command, args []string) {
-		bv, err := getBenchmarkVersion(kubeVersion, benchmarkVersion, getPlatformInfo(), viper.GetViper())
+		bv, err := resolveBenchmarkVersion(viper.GetViper())
 		if err != nil {
 			exitWithError(fmt.Errorf("unable to determine benchmark version: %v", err))
 		}
@@ -166,20 +188,36 @@ func init() {
 	RootCmd.PersistentFlags().BoolVar(&noTotals, "nototals", false, "Disable printing of totals for failed, passed, ... checks across all sections")
 	RootCmd.PersistentFlags().BoolVar(&jsonFmt, "json", false, "Prints the results as JSON")
 	RootCmd.PersistentFlags().BoolVar(&junitFmt, "junit", false, "Prints the results as JUnit")
+	RootCmd.PersistentFlags().BoolVar(&ocsfFmt, "ocsf", false, "Prints failing and warning checks as OCSF Compliance Finding events")
 	RootCmd.PersistentFlags().BoolVar(&pgSQL, "pgsql", false, "Save the results to PostgreSQL")
 	RootCmd.PersistentFlags().BoolVar(&aSFF, "asff", false, "Send the results to AWS Security Hub")
+	RootCmd.PersistentFlags().BoolVar(&aSFFResolve, "asff-resolve", false, "With --asff, archive and resolve the findings of checks that now pass. Needs the securityhub:GetFindings and securityhub:BatchUpdateFindings permissions")
+	RootCmd.PersistentFlags().BoolVar(&publishNodeResults, "publish-node-status", false, "When running in a cluster, create an Event on the node for every failing check and set its CISBenchmarkCompliant condition")
+	RootCmd.PersistentFlags().StringVar(&webhookURL, "webhook", "", "Send the results as JSON to the given URL")
+	RootCmd.PersistentFlags().StringArrayVar(&webhookHeaders, "webhook-header", []string{}, `Extra HTTP header to send with webhook requests, in the form "Name: value". Can be repeated`)
+	RootCmd.PersistentFlags().BoolVar(&webhookEvents, "webhook-events", false, "Send one webhook event per failing check instead of the whole report")
+	RootCmd.PersistentFlags().IntVar(&webhookBatchSize, "webhook-batch-size", 100, "Maximum number of events sent in a single webhook request when run with --webhook-events")
+	RootCmd.PersistentFlags().IntVar(&webhookRetries, "webhook-retries", 3, "Number of times a failed webhook request is retried")
+	RootCmd.PersistentFlags().DurationVar(&webhookTimeout, "webhook-timeout", 30*time.Second, "Timeout of a single webhook request, including reading the response")
 	RootCmd.PersistentFlags().BoolVar(&filterOpts.Scored, "scored", true, "Run the scored CIS checks")
 	RootCmd.PersistentFlags().BoolVar(&filterOpts.Unscored, "unscored", true, "Run the unscored CIS checks")
-	RootCmd.PersistentFlags().StringVar(&skipIds, "skip", "", "List of comma separated values of checks to be skipped")
+	RootCmd.PersistentFlags().StringVar(&skipIds, "skip", "", "List of comma separated values of checks to be skipped, by ID, canonical ID or alias")
 	RootCmd.PersistentFlags().BoolVar(&includeTestOutput, "include-test-output", false, "Prints the actual result when test fails")
-	RootCmd.PersistentFlags().StringVar(&outputFile, "outputfile", "", "Writes the results to output file when run with --json or --junit")
+	RootCmd.PersistentFlags().StringVar(&outputFile, "outputfile", "", "Writes the results to output file when run with --json, --junit or --ocsf")
+	RootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logFormatText, `Format of the check diagnostics written to stderr, "text" or "json"`)
+	RootCmd.PersistentFlags().StringVar(&traceFile, "trace", "", "Writes the full evaluation trace of every check to the given file as JSON")
+	RootCmd.PersistentFlags().BoolVar(&kubeletConfigzAudit, "kubelet-configz", false, "When running in a cluster, also test kubelet settings against the configuration read from the kubelet configz endpoint")
+	RootCmd.PersistentFlags().StringVar(&aliasesFile, "aliases", "", "File mapping canonical check IDs to their IDs in each benchmark (default is aliases.yaml in the config directory)")
+	RootCmd.PersistentFlags().StringVar(&attestationsFile, "attestations", "", "File recording the outcome of reviewed manual checks, reported instead of WARN (see kube-bench attest)")
+	RootCmd.PersistentFlags().StringVar(&signKeyFile, "sign-key", "", "PEM file of an ed25519 private key signing the JSON report, with the metadata of the scan (see kube-bench verify)")
+	RootCmd.PersistentFlags().StringVar(&criEndpoint, "cri-endpoint", "", "CRI socket used to find components running in containers, for example unix:///run/containerd/containerd.sock (default tries containerd, CRI-O, k3s and k0s)")
 
 	RootCmd.PersistentFlags().StringVarP(
 		&filterOpts.CheckList,
 		"check",
 		"c",
 		"",
-		`A comma-delimited list of checks to run as specified in CIS document. Example --check="1.1.1,1.1.2"`,
+		`A comma-delimited list of checks to run as specified in CIS document, or their canonical IDs or aliases. Example --check="1.1.1,1.1.2"`,
 	)
 	RootCmd.PersistentFlags().StringVarP(
 		&filterOpts.GroupList,
@@ -188,9 +226,28 @@ func init() {
 		"",
 		`Run all the checks under this comma-delimited list of groups. Example --group="1.1"`,
 	)
+	RootCmd.PersistentFlags().StringVar(
+		&filterOpts.Expression,
+		"filter",
+		"",
+		`Run only the checks matching this expression. Example --filter='group in (1.2,4.2) and scored and not id =~ "^5\\."'`,
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&filterOpts.Tags,
+		"tags",
+		"",
+		`Run only the checks with any of this comma-delimited list of tags. Only the cis-1.10, cis-1.11 and cis-1.12 benchmarks tag their checks. Example --tags="tls,audit-logging"`,
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&filterOpts.ExcludeTags,
+		"exclude-tags",
+		"",
+		`Don't run the checks with any of this comma-delimited list of tags. Example --exclude-tags="file-permissions"`,
+	)
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./cfg/config.yaml)")
 	RootCmd.PersistentFlags().StringVarP(&cfgDir, "config-dir", "D", cfgDir, "config directory")
 	RootCmd.PersistentFlags().StringVar(&kubeVersion, "version", "", "Manually specify Kubernetes version, automatically detected if unset")
+	RootCmd.PersistentFlags().BoolVar(&strictVersion, "strict-version", false, fmt.Sprintf("Fail when the Kubernetes version can't be detected, instead of assuming %s", defaultKubeVersion))
 	RootCmd.PersistentFlags().StringVar(&benchmarkVersion, "benchmark", "", "Manually specify CIS benchmark version. It would be an error to specify both --version and --benchmark flags")
 
 	if err := goflag.Set("logtostderr", "true"); err != nil {
@@ -233,5 +290,7 @@ func initConfig() {
 			colorPrint(check.FAIL, fmt.Sprintf("Failed to read config file: %v\n", err))
 			os.Exit(1)
 		}
+	} else {
+		recordConfigFile(viper.ConfigFileUsed())
 	}
 }
@@ -27,12 +27,25 @@ var runCmd = &cobra.Command{
 	Short: "Run tests",
 	Long:  `Run tests. If no arguments are specified, runs tests from all files`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if profileName != "" {
+			profile, err := getProfile(profileName, viper.GetViper())
+			if err != nil {
+				exitWithError(err)
+			}
+			if err := validateProfile(profile, cfgDir, viper.GetViper()); err != nil {
+				exitWithError(fmt.Errorf("invalid profile %s: %v", profile.Name, err))
+			}
+			if err := applyProfile(cmd, profile); err != nil {
+				exitWithError(err)
+			}
+		}
+
 		targets, err := cmd.Flags().GetStringSlice("targets")
 		if err != nil {
 			exitWithError(fmt.Errorf("unable to get `targets` from command line :%v", err))
 		}
 
-		bv, err := getBenchmarkVersion(kubeVersion, benchmarkVersion, getPlatformInfo(), viper.GetViper())
+		bv, err := resolveBenchmarkVersion(viper.GetViper())
 		if err != nil {
 			exitWithError(fmt.Errorf("unable to get benchmark version. error: %v", err))
 		}
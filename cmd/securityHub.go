@@ -15,7 +15,9 @@ import (
 // REGION ...
 const REGION = "AWS_REGION"
 
-func writeFinding(in []types.AwsSecurityFinding) error {
+// writeFinding imports the active findings and archives the resolved findings
+// which are still active in AWS Security Hub, if any.
+func writeFinding(in, resolved []types.AwsSecurityFinding) error {
 	r := viper.GetString(REGION)
 	if len(r) == 0 {
 		return fmt.Errorf("%s not set", REGION)
@@ -26,10 +28,28 @@ func writeFinding(in []types.AwsSecurityFinding) error {
 	}
 
 	svc := securityhub.NewFromConfig(cfg)
-	p := findings.New(*svc)
+	return publishFindings(findings.New(svc), in, resolved)
+}
+
+// publishFindings imports the active findings, then archives and resolves the resolved findings,
+// which are only given with --asff-resolve.
+func publishFindings(p *findings.Publisher, in, resolved []types.AwsSecurityFinding) error {
 	out, perr := p.PublishFinding(in)
 	print(out)
-	return perr
+	if perr != nil {
+		return perr
+	}
+	if len(resolved) == 0 {
+		return nil
+	}
+
+	log.Printf("Archiving resolved findings")
+	out, perr = p.ArchiveResolved(resolved)
+	print(out)
+	if perr != nil {
+		return fmt.Errorf("unable to archive resolved findings: %v", perr)
+	}
+	return nil
 }
 
 func print(out *findings.PublisherOutput) {
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aquasecurity/kube-bench/internal/findings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// importOnlyClient is allowed BatchImportFindings, but not GetFindings.
+type importOnlyClient struct {
+	imported int
+}
+
+func (c *importOnlyClient) BatchImportFindings(ctx context.Context, params *securityhub.BatchImportFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.BatchImportFindingsOutput, error) {
+	c.imported += len(params.Findings)
+	return &securityhub.BatchImportFindingsOutput{
+		FailedCount:  aws.Int32(0),
+		SuccessCount: aws.Int32(int32(len(params.Findings))),
+	}, nil
+}
+
+func (c *importOnlyClient) BatchUpdateFindings(ctx context.Context, params *securityhub.BatchUpdateFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.BatchUpdateFindingsOutput, error) {
+	return nil, errors.New("AccessDeniedException: not authorized to perform securityhub:BatchUpdateFindings")
+}
+
+func (c *importOnlyClient) GetFindings(ctx context.Context, params *securityhub.GetFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.GetFindingsOutput, error) {
+	return nil, errors.New("AccessDeniedException: not authorized to perform securityhub:GetFindings")
+}
+
+func TestPublishFindings(t *testing.T) {
+	in := []types.AwsSecurityFinding{{Id: aws.String("1.1.1")}, {Id: aws.String("1.1.2")}}
+
+	// Without --asff-resolve, no resolved findings are given, and only BatchImportFindings is needed
+	client := &importOnlyClient{}
+	assert.NoError(t, publishFindings(findings.New(client), in, nil))
+	assert.Equal(t, 2, client.imported)
+
+	// With it, failing to archive them fails the output
+	client = &importOnlyClient{}
+	err := publishFindings(findings.New(client), in, []types.AwsSecurityFinding{{Id: aws.String("1.1.3")}})
+	assert.ErrorContains(t, err, "unable to archive resolved findings")
+	assert.Equal(t, 2, client.imported)
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// signingKey signs the JSON report when set with --sign-key.
+	signingKey ed25519.PrivateKey
+	// verifyKeyFile is the public key file given to kube-bench verify with --key.
+	verifyKeyFile string
+	// configFilesRead are the config and controls files read by the scan, reported in the metadata of signed reports.
+	configFilesRead = make(map[string]bool)
+)
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyKeyFile, "key", "", "PEM file of the ed25519 public key the report was signed with")
+	if err := verifyCmd.MarkFlagRequired("key"); err != nil {
+		panic(err)
+	}
+	RootCmd.AddCommand(verifyCmd)
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <report>",
+	Short: "Verify the signature of a JSON report signed with --sign-key",
+	Long: `Verify the signature of a JSON report signed with --sign-key, and show the metadata of the scan
+that produced it. The exit code is 1 if the report isn't signed with the key or was modified.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := loadPublicKey(verifyKeyFile)
+		if err != nil {
+			exitWithError(err)
+		}
+		in, err := os.ReadFile(args[0])
+		if err != nil {
+			exitWithError(fmt.Errorf("error reading report %s: %v", args[0], err))
+		}
+		report, err := check.VerifyReport(in, key)
+		if err != nil {
+			exitWithError(fmt.Errorf("report %s: %v", args[0], err))
+		}
+		printReportVerification(os.Stdout, report)
+	},
+}
+
+// loadSigningOptions loads the key given with --sign-key, which signs the JSON report with its totals.
+// It's called when the output is written, by kube-bench and kube-bench run alike, once a profile
+// has set the output flags.
+func loadSigningOptions() error {
+	if signKeyFile == "" {
+		return nil
+	}
+	if !jsonFmt {
+		return errors.New("--sign-key signs the JSON report, and needs --json")
+	}
+	if noTotals {
+		return errors.New("--sign-key can't be used with --noTotals")
+	}
+	key, err := loadSigningKey(signKeyFile)
+	if err != nil {
+		return err
+	}
+	signingKey = key
+	return nil
+}
+
+// loadSigningKey reads an ed25519 private key from a PEM file in PKCS #8 form, such as
+// one made with openssl genpkey -algorithm ed25519.
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMFile(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key %s: %v", path, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s isn't an ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// loadPublicKey reads an ed25519 public key from a PEM file in PKIX form, such as
+// one made with openssl pkey -pubout.
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEMFile(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %v", path, err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s isn't an ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+func readPEMFile(path, blockType string) (*pem.Block, error) {
+	in, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file %s: %v", path, err)
+	}
+	block, _ := pem.Decode(in)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("key file %s has no PEM %s block", path, blockType)
+	}
+	return block, nil
+}
+
+// recordConfigFile records that the scan read a config or controls file.
+func recordConfigFile(path string) {
+	if path != "" {
+		configFilesRead[path] = true
+	}
+}
+
+// reportMetadata describes the scan that produced controlsCollection.
+func reportMetadata(controlsCollection []*check.Controls, now time.Time) (*check.ReportMetadata, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the host name: %v", err)
+	}
+
+	var benchmarks []string
+	for _, controls := range controlsCollection {
+		benchmarks = appendUnique(benchmarks, controls.Version)
+	}
+
+	files := make(map[string]string, len(configFilesRead))
+	for path := range configFilesRead {
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = sum
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the kube-bench binary: %v", err)
+	}
+	binarySum, err := fileSHA256(exe)
+	if err != nil {
+		return nil, err
+	}
+
+	return &check.ReportMetadata{
+		Host:             host,
+		Time:             now.UTC().Format(time.RFC3339),
+		Benchmark:        strings.Join(benchmarks, ","),
+		KubeBenchVersion: KubeBenchVersion,
+		ConfigFiles:      files,
+		BinarySHA256:     binarySum,
+	}, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to hash %s: %v", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("unable to hash %s: %v", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// signedJSONReport returns the JSON of a report with the metadata of the scan, signed with key.
+func signedJSONReport(report check.OverallControls, key ed25519.PrivateKey) ([]byte, error) {
+	metadata, err := reportMetadata(report.Controls, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	report.Metadata = metadata
+	out, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	return check.SignReport(out, key)
+}
+
+func printReportVerification(w io.Writer, report *check.OverallControls) {
+	fmt.Fprintf(w, "Signature verified with key %s\n", report.Signature.KeyID)
+	m := report.Metadata
+	if m == nil {
+		fmt.Fprintln(w, "The report has no scan metadata")
+		return
+	}
+	fmt.Fprintf(w, "Host: %s\n", m.Host)
+	fmt.Fprintf(w, "Time: %s\n", m.Time)
+	fmt.Fprintf(w, "Benchmark: %s\n", m.Benchmark)
+	fmt.Fprintf(w, "kube-bench version: %s\n", m.KubeBenchVersion)
+	fmt.Fprintf(w, "kube-bench binary SHA-256: %s\n", m.BinarySHA256)
+	fmt.Fprintln(w, "Config files SHA-256:")
+	paths := make([]string, 0, len(m.ConfigFiles))
+	for path := range m.ConfigFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(w, "\t %s %s\n", m.ConfigFiles[path], path)
+	}
+}
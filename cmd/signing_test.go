@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadSigningKeys(t *testing.T) {
+	dir := t.TempDir()
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "key.pem"), "PRIVATE KEY", der)
+	der, err = x509.MarshalPKIXPublicKey(pub)
+	assert.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "pub.pem"), "PUBLIC KEY", der)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err = x509.MarshalPKCS8PrivateKey(ecKey)
+	assert.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "ec-key.pem"), "PRIVATE KEY", der)
+	der, err = x509.MarshalPKIXPublicKey(ecKey.Public())
+	assert.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "ec-pub.pem"), "PUBLIC KEY", der)
+
+	loadedKey, err := loadSigningKey(filepath.Join(dir, "key.pem"))
+	assert.NoError(t, err)
+	assert.True(t, key.Equal(loadedKey))
+	loadedPub, err := loadPublicKey(filepath.Join(dir, "pub.pem"))
+	assert.NoError(t, err)
+	assert.True(t, pub.Equal(loadedPub))
+
+	_, err = loadSigningKey(filepath.Join(dir, "ec-key.pem"))
+	assert.ErrorContains(t, err, "isn't an ed25519 key")
+	_, err = loadPublicKey(filepath.Join(dir, "ec-pub.pem"))
+	assert.ErrorContains(t, err, "isn't an ed25519 key")
+	_, err = loadSigningKey(filepath.Join(dir, "pub.pem"))
+	assert.ErrorContains(t, err, "has no PEM PRIVATE KEY block")
+	_, err = loadPublicKey(filepath.Join(dir, "missing.pem"))
+	assert.ErrorContains(t, err, "error reading key file")
+}
+
+func TestLoadSigningOptions(t *testing.T) {
+	defer func(file string, json, totals bool) {
+		signKeyFile, jsonFmt, noTotals, signingKey = file, json, totals, nil
+	}(signKeyFile, jsonFmt, noTotals)
+
+	signKeyFile, jsonFmt, noTotals = "", false, false
+	assert.NoError(t, loadSigningOptions())
+	assert.Nil(t, signingKey)
+
+	signKeyFile = "key.pem"
+	assert.EqualError(t, loadSigningOptions(), "--sign-key signs the JSON report, and needs --json")
+
+	jsonFmt, noTotals = true, true
+	assert.EqualError(t, loadSigningOptions(), "--sign-key can't be used with --noTotals")
+}
+
+func TestSignedJSONReport(t *testing.T) {
+	defer func(files map[string]bool) { configFilesRead = files }(configFilesRead)
+	configFilesRead = make(map[string]bool)
+	recordConfigFile("../cfg/cis-1.12/node.yaml")
+	recordConfigFile("../cfg/cis-1.12/config.yaml")
+	recordConfigFile("")
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	controlsCollection := []*check.Controls{{ID: "4", Version: "cis-1.12"}, {ID: "5", Version: "cis-1.12"}}
+
+	out, err := signedJSONReport(check.OverallControls{Controls: controlsCollection, Totals: check.Summary{Pass: 1}}, key)
+	assert.NoError(t, err)
+
+	report, err := check.VerifyReport(out, pub)
+	assert.NoError(t, err)
+	m := report.Metadata
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, m.Host)
+	assert.Equal(t, "cis-1.12", m.Benchmark)
+	assert.Len(t, m.BinarySHA256, 64)
+	assert.Len(t, m.ConfigFiles, 2)
+	assert.Len(t, m.ConfigFiles["../cfg/cis-1.12/node.yaml"], 64)
+	assert.Equal(t, 1, report.Totals.Pass)
+
+	recordConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	_, err = signedJSONReport(check.OverallControls{Controls: controlsCollection}, key)
+	assert.ErrorContains(t, err, "unable to hash")
+}
+
+func TestPrintReportVerification(t *testing.T) {
+	var out bytes.Buffer
+	printReportVerification(&out, &check.OverallControls{
+		Signature: &check.ReportSignature{KeyID: "0123"},
+		Metadata: &check.ReportMetadata{
+			Host:             "node-1",
+			Time:             time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			Benchmark:        "cis-1.12",
+			KubeBenchVersion: "v0.12.0",
+			ConfigFiles:      map[string]string{"cfg/cis-1.12/node.yaml": "bbb", "cfg/config.yaml": "aaa"},
+			BinarySHA256:     "ccc",
+		},
+	})
+	assert.Equal(t, `Signature verified with key 0123
+Host: node-1
+Time: 2026-10-19T09:00:00Z
+Benchmark: cis-1.12
+kube-bench version: v0.12.0
+kube-bench binary SHA-256: ccc
+Config files SHA-256:
+	 bbb cfg/cis-1.12/node.yaml
+	 aaa cfg/config.yaml
+`, out.String())
+}
+
+func TestWriteOutputSignsReport(t *testing.T) {
+	defer func(file, out string, json, totals bool) {
+		signKeyFile, outputFile, jsonFmt, noTotals, signingKey = file, out, json, totals, nil
+	}(signKeyFile, outputFile, jsonFmt, noTotals)
+
+	dir := t.TempDir()
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "key.pem"), "PRIVATE KEY", der)
+
+	// kube-bench without a subcommand writes its output with writeOutput, as kube-bench run does
+	signKeyFile, outputFile, jsonFmt, noTotals = filepath.Join(dir, "key.pem"), filepath.Join(dir, "report.json"), true, false
+	writeOutput([]*check.Controls{{ID: "4", Version: "cis-1.12"}})
+
+	out, err := os.ReadFile(outputFile)
+	assert.NoError(t, err)
+	report, err := check.VerifyReport(out, pub)
+	assert.NoError(t, err)
+	assert.Equal(t, "cis-1.12", report.Metadata.Benchmark)
+}
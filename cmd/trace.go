@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/aquasecurity/kube-bench/check"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// loggingRunner wraps a Runner and emits a structured log event for every
+// step of each check it runs.
+type loggingRunner struct {
+	runner   check.Runner
+	logger   *slog.Logger
+	nodeType check.NodeType
+}
+
+func (r *loggingRunner) Run(c *check.Check) check.State {
+	state := r.runner.Run(c)
+	logCheckTrace(r.logger.With("node_type", r.nodeType, "test_number", c.ID), c.Trace)
+	return state
+}
+
+// newCheckRunner returns the Runner used for the checks of nodetype, as
+// selected by --log-format.
+func newCheckRunner(nodetype check.NodeType) (check.Runner, error) {
+	runner := check.NewRunner()
+	switch logFormat {
+	case logFormatText, "":
+		return runner, nil
+	case logFormatJSON:
+		return &loggingRunner{
+			runner:   runner,
+			logger:   newJSONLogger(os.Stderr),
+			nodeType: nodetype,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %q or %q", logFormat, logFormatText, logFormatJSON)
+	}
+}
+
+func newJSONLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, nil))
+}
+
+func logCheckTrace(logger *slog.Logger, tr *check.Trace) {
+	if tr == nil {
+		return
+	}
+
+	logger.Info("check start", "event", "check_start", "start", tr.Start)
+	for _, a := range tr.Audits {
+		logger.Info("audit",
+			"event", "audit",
+			"source", a.Source,
+			"command", a.Command,
+			"exit_status", a.ExitStatus,
+			"duration_ms", a.DurationMs,
+		)
+	}
+	for _, t := range tr.Tests {
+		logger.Info("test",
+			"event", "test",
+			"flag", t.Flag,
+			"path", t.Path,
+			"env", t.Env,
+			"audit_used", t.AuditUsed,
+			"result", t.Result,
+			"expected_result", t.ExpectedResult,
+		)
+	}
+	logger.Info("check end",
+		"event", "check_end",
+		"status", tr.State,
+		"reason", tr.Reason,
+		"duration_ms", tr.DurationMs,
+	)
+}
+
+// checkTrace is the trace of a check as written to the --trace file.
+type checkTrace struct {
+	ControlsID string         `json:"id"`
+	NodeType   check.NodeType `json:"node_type"`
+	Section    string         `json:"section"`
+	*check.Trace
+}
+
+func getCheckTraces(controlsCollection []*check.Controls) []checkTrace {
+	traces := []checkTrace{}
+	for _, controls := range controlsCollection {
+		for _, g := range controls.Groups {
+			for _, c := range g.Checks {
+				if c.Trace == nil {
+					continue
+				}
+				traces = append(traces, checkTrace{
+					ControlsID: controls.ID,
+					NodeType:   controls.Type,
+					Section:    g.ID,
+					Trace:      c.Trace,
+				})
+			}
+		}
+	}
+	return traces
+}
+
+func writeTraceOutput(controlsCollection []*check.Controls) {
+	out, err := json.MarshalIndent(getCheckTraces(controlsCollection), "", "  ")
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to format trace: %v", err))
+	}
+	if err := writeOutputToFile(string(out), traceFile); err != nil {
+		exitWithError(fmt.Errorf("Failed to write to trace file %s: %v", traceFile, err))
+	}
+}
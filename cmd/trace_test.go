@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCheckRunner(t *testing.T) {
+	defer func() { logFormat = logFormatText }()
+
+	logFormat = logFormatText
+	runner, err := newCheckRunner(check.MASTER)
+	assert.NoError(t, err)
+	assert.NotNil(t, runner)
+
+	logFormat = logFormatJSON
+	runner, err = newCheckRunner(check.MASTER)
+	assert.NoError(t, err)
+	assert.IsType(t, &loggingRunner{}, runner)
+
+	logFormat = "xml"
+	_, err = newCheckRunner(check.MASTER)
+	assert.Error(t, err)
+}
+
+func TestLoggingRunner(t *testing.T) {
+	var buf bytes.Buffer
+	runner := &loggingRunner{
+		runner:   check.NewRunner(),
+		logger:   newJSONLogger(&buf),
+		nodeType: check.NODE,
+	}
+
+	controls, err := check.NewControls(check.NODE, []byte(`
+type: "node"
+groups:
+- id: 4.2
+  checks:
+  - id: 4.2.1
+    audit: "echo '--anonymous-auth=false'"
+    tests:
+      test_items:
+      - flag: "--anonymous-auth"
+        compare:
+          op: eq
+          value: false
+    scored: true
+`), "")
+	assert.NoError(t, err)
+	c := controls.Groups[0].Checks[0]
+	state := runner.Run(c)
+	assert.Equal(t, check.PASS, state)
+
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &e))
+		events = append(events, e)
+	}
+
+	if assert.Len(t, events, 4) {
+		assert.Equal(t, "check_start", events[0]["event"])
+		assert.Equal(t, "audit", events[1]["event"])
+		assert.Equal(t, "auditCommand", events[1]["source"])
+		assert.Equal(t, float64(0), events[1]["exit_status"])
+		assert.Equal(t, "test", events[2]["event"])
+		assert.Equal(t, "auditCommand", events[2]["audit_used"])
+		assert.Equal(t, "check_end", events[3]["event"])
+		assert.Equal(t, "PASS", events[3]["status"])
+		for _, e := range events {
+			assert.Equal(t, "4.2.1", e["test_number"])
+			assert.Equal(t, "node", e["node_type"])
+		}
+	}
+}
+
+func TestGetCheckTraces(t *testing.T) {
+	tr := &check.Trace{CheckID: "1.1.1", Start: time.Now(), State: check.PASS}
+	controlsCollection := []*check.Controls{
+		{
+			ID:   "1",
+			Type: check.MASTER,
+			Groups: []*check.Group{
+				{ID: "1.1", Checks: []*check.Check{{ID: "1.1.1", Trace: tr}, {ID: "1.1.2"}}},
+			},
+		},
+	}
+
+	traces := getCheckTraces(controlsCollection)
+	if assert.Len(t, traces, 1) {
+		out, err := json.Marshal(traces[0])
+		assert.NoError(t, err)
+		assert.Contains(t, string(out), `"node_type":"master"`)
+		assert.Contains(t, string(out), `"section":"1.1"`)
+		assert.Contains(t, string(out), `"test_number":"1.1.1"`)
+		assert.Contains(t, string(out), `"status":"PASS"`)
+	}
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,12 +10,12 @@ import (
 	"strings"
 
 	"github.com/aquasecurity/kube-bench/check"
+	"github.com/aquasecurity/kube-bench/internal/cri"
+	"github.com/aquasecurity/kube-bench/internal/procfs"
 	"github.com/fatih/color"
 	"github.com/golang/glog"
 	"github.com/spf13/viper"
-	"golang.org/x/exp/slices"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -32,6 +30,8 @@ var colors = map[check.State]*color.Color{
 
 var (
 	psFunc          func(string) string
+	pidsFunc        func(string) []int
+	containersFunc  func() []cri.Container
 	statFunc        func(string) (os.FileInfo, error)
 	getBinariesFunc func(*viper.Viper, check.NodeType) (map[string]string, error)
 	TypeMap         = map[string][]string{
@@ -45,6 +45,8 @@ var (
 
 func init() {
 	psFunc = ps
+	pidsFunc = pids
+	containersFunc = readContainers
 	statFunc = os.Stat
 	getBinariesFunc = getBinaries
 }
@@ -79,11 +81,26 @@ func cleanIDs(list string) map[string]bool {
 	return set
 }
 
-// ps execs out to the ps command; it's separated into a function so we can write tests
+// ps returns the command lines of the running processes named proc, one per row.
+// It reads them from /proc, and only execs out to the ps command if /proc can't be read;
+// it's separated into a function so we can write tests
 func ps(proc string) string {
-	// TODO: truncate proc to 15 chars
-	// See https://github.com/aquasecurity/kube-bench/issues/328#issuecomment-506813344
 	glog.V(2).Info(fmt.Sprintf("ps - proc: %q", proc))
+	procs, err := procfs.NewScanner("").Find(proc)
+	if err != nil {
+		glog.V(2).Info(fmt.Sprintf("ps - unable to read processes, using the ps command: %v", err))
+		return execPs(proc)
+	}
+
+	var out strings.Builder
+	for _, p := range procs {
+		out.WriteString(p.CommandLine() + "\n")
+	}
+	glog.V(2).Info(fmt.Sprintf("ps - returning: %q", out.String()))
+	return out.String()
+}
+
+func execPs(proc string) string {
 	cmd := exec.Command("/bin/ps", "-C", proc, "-o", "cmd", "--no-headers")
 	out, err := cmd.Output()
 	if err != nil {
@@ -94,6 +111,47 @@ func ps(proc string) string {
 	return string(out)
 }
 
+// pids returns the PIDs of the running processes of bin, matched as verifyBin does,
+// in increasing order; it's separated into a function so we can write tests
+func pids(bin string) []int {
+	bin = strings.Trim(bin, "'\"")
+	if isEmpty(bin) {
+		return nil
+	}
+	procs, err := procfs.NewScanner("").Find(strings.Fields(bin)[0])
+	if err != nil {
+		glog.V(2).Info(fmt.Sprintf("pids - unable to read processes: %v", err))
+		return nil
+	}
+
+	var found []int
+	for _, p := range procs {
+		if matchesBin(bin, p.CommandLine()) {
+			found = append(found, p.PID)
+		}
+	}
+	return found
+}
+
+// getPids finds the PID of the running process of each component in binmap. When a component
+// has several processes, such as two kubelets, the one with the lowest PID is audited.
+func getPids(binmap map[string]string) map[string]string {
+	pidmap := make(map[string]string)
+	for component, bin := range binmap {
+		found := pidsFunc(bin)
+		if len(found) == 0 {
+			continue
+		}
+		if len(found) > 1 {
+			glog.Warningf("Found %d processes for %s %v, auditing PID %d", len(found), bin, found, found[0])
+		} else {
+			glog.V(2).Info(fmt.Sprintf("Component %s runs as PID %d", component, found[0]))
+		}
+		pidmap[component] = strconv.Itoa(found[0])
+	}
+	return pidmap
+}
+
 // getBinaries finds which of the set of candidate executables are running.
 // It returns an error if one mandatory executable is not running.
 func getBinaries(v *viper.Viper, nodetype check.NodeType) (map[string]string, error) {
@@ -109,6 +167,11 @@ func getBinaries(v *viper.Viper, nodetype check.NodeType) (map[string]string, er
 		bins := s.GetStringSlice("bins")
 		if len(bins) > 0 {
 			bin, err := findExecutable(bins)
+			if err != nil {
+				if cbin, ctr := findContainer(containersFunc(), bins); ctr != nil {
+					bin, err = cbin, nil
+				}
+			}
 			if err != nil && !optional {
 				glog.V(1).Info(buildComponentMissingErrorMessage(nodetype, component, bins))
 				return nil, fmt.Errorf("unable to detect running programs for component %q", component)
@@ -192,8 +255,13 @@ func getFiles(v *viper.Viper, fileType string) map[string]string {
 			continue
 		}
 
-		// See if any of the candidate files exist
+		// See if any of the candidate files exist, on the host or mounted into the component's container
 		file := findConfigFile(s.GetStringSlice(mainOpt))
+		if file == "" && len(s.GetStringSlice(mainOpt)) > 0 {
+			if _, ctr := findContainer(containersFunc(), s.GetStringSlice("bins")); ctr != nil {
+				file = findContainerFile(ctr, s.GetStringSlice(mainOpt))
+			}
+		}
 		if file == "" {
 			if s.IsSet(defaultOpt) {
 				file = s.GetString(defaultOpt)
@@ -217,6 +285,9 @@ func getFiles(v *viper.Viper, fileType string) map[string]string {
 func verifyBin(bin string) bool {
 	// Strip any quotes
 	bin = strings.Trim(bin, "'\"")
+	if isEmpty(bin) {
+		return false
+	}
 
 	// bin could consist of more than one word
 	// We'll search for running processes with the first word, and then check the whole
@@ -228,11 +299,9 @@ func verifyBin(bin string) bool {
 	// The binary needs to be the first word in the ps output, except that it could be preceded by a path
 	// e.g. /usr/bin/kubelet is a match for kubelet
 	// but apiserver is not a match for kube-apiserver
-	reFirstWord := regexp.MustCompile(`^(\S*\/)*` + bin)
 	lines := strings.Split(out, "\n")
 	for _, l := range lines {
-		glog.V(3).Info(fmt.Sprintf("reFirstWord.Match(%s)", l))
-		if reFirstWord.Match([]byte(l)) {
+		if matchesBin(bin, l) {
 			return true
 		}
 	}
@@ -240,6 +309,14 @@ func verifyBin(bin string) bool {
 	return false
 }
 
+// matchesBin reports whether bin is the first word of the command line l,
+// except that it could be preceded by a path
+func matchesBin(bin string, l string) bool {
+	reFirstWord := regexp.MustCompile(`^(\S*\/)*` + bin)
+	glog.V(3).Info(fmt.Sprintf("reFirstWord.Match(%s)", l))
+	return reFirstWord.MatchString(l)
+}
+
 // fundConfigFile looks through a list of possible config files and finds the first one that exists
 func findConfigFile(candidates []string) string {
 	for _, c := range candidates {
@@ -276,142 +353,20 @@ func multiWordReplace(s string, subname string, sub string) string {
 	return strings.Replace(s, subname, sub, -1)
 }
 
-const missingKubectlKubeletMessage = `
-Unable to find the programs kubectl or kubelet in the PATH.
-These programs are used to determine which version of Kubernetes is running.
-Make sure the /usr/local/mount-from-host/bin directory is mapped to the container,
-either in the job.yaml file, or Docker command.
-
-For job.yaml:
-...
-- name: usr-bin
-  mountPath: /usr/local/mount-from-host/bin
-...
-
-For docker command:
-   docker -v $(which kubectl):/usr/local/mount-from-host/bin/kubectl ....
-
-Alternatively, you can specify the version with --version
-   kube-bench --version <VERSION> ...
-`
-
-func getKubeVersion() (*KubeVersion, error) {
+// getInClusterClient returns a client for the cluster kube-bench is running in.
+func getInClusterClient() (kubernetes.Interface, error) {
 	kubeConfig, err := rest.InClusterConfig()
 	if err != nil {
 		glog.V(3).Infof("Error fetching cluster config: %s", err)
+		return nil, err
 	}
-	isRKE := false
-	isAKS := false
-	if err == nil && kubeConfig != nil {
-		k8sClient, err := kubernetes.NewForConfig(kubeConfig)
-		if err != nil {
-			glog.V(3).Infof("Failed to fetch k8sClient object from kube config : %s", err)
-		}
 
-		if err == nil {
-			isRKE, err = IsRKE(context.Background(), k8sClient)
-			if err != nil {
-				glog.V(3).Infof("Error detecting RKE cluster: %s", err)
-			}
-			isAKS, err = IsAKS(context.Background(), k8sClient)
-			if err != nil {
-				glog.V(3).Infof("Error detecting AKS cluster: %s", err)
-			}
-		}
-
-	}
-
-	if k8sVer, err := getKubeVersionFromRESTAPI(); err == nil {
-		glog.V(2).Info(fmt.Sprintf("Kubernetes REST API Reported version: %s", k8sVer))
-		if isRKE {
-			k8sVer.GitVersion = k8sVer.GitVersion + "-rancher1"
-		}
-		if isAKS {
-			k8sVer.GitVersion = k8sVer.GitVersion + "-aks1"
-		}
-		return k8sVer, nil
-	}
-
-	// These executables might not be on the user's path.
-	_, err = exec.LookPath("kubectl")
+	k8sClient, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
-		glog.V(3).Infof("Error locating kubectl: %s", err)
-		_, err = exec.LookPath("kubelet")
-		if err != nil {
-			glog.V(3).Infof("Error locating kubelet: %s", err)
-			// Search for the kubelet binary all over the filesystem and run the first match to get the kubernetes version
-			cmd := exec.Command("/bin/sh", "-c", "`find / -type f -executable -name kubelet 2>/dev/null | grep -m1 .` --version")
-			out, err := cmd.CombinedOutput()
-			if err == nil {
-				glog.V(3).Infof("Found kubelet and query kubernetes version is: %s", string(out))
-				return getVersionFromKubeletOutput(string(out)), nil
-			}
-
-			glog.Warning(missingKubectlKubeletMessage)
-			glog.V(1).Info("unable to find the programs kubectl or kubelet in the PATH")
-			glog.V(1).Infof("Cant detect version, assuming default %s", defaultKubeVersion)
-			return &KubeVersion{baseVersion: defaultKubeVersion}, nil
-		}
-		return getKubeVersionFromKubelet(), nil
+		glog.V(3).Infof("Failed to fetch k8sClient object from kube config : %s", err)
+		return nil, err
 	}
-
-	return getKubeVersionFromKubectl(), nil
-}
-
-func getKubeVersionFromKubectl() *KubeVersion {
-	cmd := exec.Command("kubectl", "version", "-o", "json")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		glog.V(2).Infof("Failed to query kubectl: %s", err)
-		glog.V(2).Info(err)
-	}
-
-	return getVersionFromKubectlOutput(string(out))
-}
-
-func getKubeVersionFromKubelet() *KubeVersion {
-	cmd := exec.Command("kubelet", "--version")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		glog.V(2).Infof("Failed to query kubelet: %s", err)
-		glog.V(2).Info(err)
-	}
-
-	return getVersionFromKubeletOutput(string(out))
-}
-
-func getVersionFromKubectlOutput(s string) *KubeVersion {
-	glog.V(2).Infof("Kubectl output: %s", s)
-	type versionResult struct {
-		ServerVersion VersionResponse
-	}
-	vrObj := &versionResult{}
-	if err := json.Unmarshal([]byte(s), vrObj); err != nil {
-		glog.V(2).Info(err)
-		if strings.Contains(s, "The connection to the server") {
-			msg := fmt.Sprintf(`Warning: Kubernetes version was not auto-detected because kubectl could not connect to the Kubernetes server. This may be because the kubeconfig information is missing or has credentials that do not match the server. Assuming default version %s`, defaultKubeVersion)
-			fmt.Fprintln(os.Stderr, msg)
-		}
-		glog.V(1).Info(fmt.Sprintf("Unable to get Kubernetes version from kubectl, using default version: %s", defaultKubeVersion))
-		return &KubeVersion{baseVersion: defaultKubeVersion}
-	}
-	sv := vrObj.ServerVersion
-	return &KubeVersion{
-		Major:      sv.Major,
-		Minor:      sv.Minor,
-		GitVersion: sv.GitVersion,
-	}
-}
-
-func getVersionFromKubeletOutput(s string) *KubeVersion {
-	glog.V(2).Infof("Kubelet output: %s", s)
-	serverVersionRe := regexp.MustCompile(`Kubernetes v(\d+.\d+)`)
-	subs := serverVersionRe.FindStringSubmatch(s)
-	if len(subs) < 2 {
-		glog.V(1).Info(fmt.Sprintf("Unable to get Kubernetes version from kubelet, using default version: %s", defaultKubeVersion))
-		return &KubeVersion{baseVersion: defaultKubeVersion}
-	}
-	return &KubeVersion{baseVersion: subs[1]}
+	return k8sClient, nil
 }
 
 func makeSubstitutions(s string, ext string, m map[string]string) (string, []string) {
@@ -467,266 +422,3 @@ These program names are provided in the config.yaml, section '%s.%s.bins'
 
 	return fmt.Sprintf(errMessageTemplate, component, componentRoleName, binList, componentType, component)
 }
-
-func getPlatformInfo() Platform {
-
-	openShiftInfo := getOpenShiftInfo()
-	if openShiftInfo.Name != "" && openShiftInfo.Version != "" {
-		return openShiftInfo
-	}
-
-	kv, err := getKubeVersion()
-	if err != nil {
-		glog.V(2).Info(err)
-		return Platform{}
-	}
-	return getPlatformInfoFromVersion(kv.GitVersion)
-}
-
-func getPlatformInfoFromVersion(s string) Platform {
-	versionRe := regexp.MustCompile(`v(\d+\.\d+)\.\d+[-+](\w+)(?:[.\-+]*)\w+`)
-	subs := versionRe.FindStringSubmatch(s)
-	if len(subs) < 3 {
-		return Platform{}
-	}
-	return Platform{
-		Name:    subs[2],
-		Version: subs[1],
-	}
-}
-
-func IsAKS(ctx context.Context, k8sClient kubernetes.Interface) (bool, error) {
-	nodes, err := k8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
-	if err != nil {
-		return false, err
-	}
-
-	if len(nodes.Items) == 0 {
-		return false, nil
-	}
-
-	node := nodes.Items[0]
-	labels := node.Labels
-	if _, exists := labels["kubernetes.azure.com/cluster"]; exists {
-		return true, nil
-	}
-
-	if strings.HasPrefix(node.Spec.ProviderID, "azure://") {
-		return true, nil
-	}
-
-	return false, nil
-}
-
-func getPlatformBenchmarkVersion(platform Platform) string {
-	glog.V(3).Infof("getPlatformBenchmarkVersion platform: %s", platform)
-
-	switch platform.Name {
-	case "eks":
-		return eksBenchmark(platform.Version)
-	case "aks":
-		return aksBenchmark(platform.Version)
-	case "gke":
-		return gkeBenchmark(platform.Version)
-	case "ocp":
-		return ocpBenchmark(platform.Version)
-	case "k3s":
-		return k3sBenchmark(platform.Version)
-	case "rancher":
-		return rkeBenchmark(platform.Version)
-	case "rke2r":
-		return rke2Benchmark(platform.Version)
-	case "aliyun":
-		return "ack-1.0"
-	case "vmware":
-		return "tkgi-1.2.53"
-	default:
-		return ""
-	}
-}
-
-func eksBenchmark(version string) string {
-	switch version {
-	case "1.15", "1.16", "1.17", "1.18", "1.19":
-		return "eks-1.0.1"
-	case "1.29", "1.30", "1.31":
-		return "eks-1.7.0"
-	case "1.32", "1.33", "1.34":
-		return "eks-1.8.0"
-	default:
-		return "eks-1.5.0"
-	}
-}
-
-func aksBenchmark(version string) string {
-	switch version {
-	case "1.19", "1.20", "1.21", "1.22", "1.23", "1.24":
-		return "aks-1.0"
-	case "1.29", "1.30", "1.31":
-		return "aks-1.7"
-	default:
-		return "aks-1.8"
-	}
-}
-
-func gkeBenchmark(version string) string {
-	switch version {
-	case "1.15", "1.16", "1.17", "1.18", "1.19":
-		return "gke-1.0"
-	case "1.20", "1.21", "1.22", "1.23", "1.24", "1.25", "1.26", "1.27":
-		return "gke-1.2.0"
-	case "1.28", "1.29":
-		return "gke-1.6.0"
-	case "1.30":
-		return "gke-1.8.0"
-	case "1.31", "1.32", "1.33", "1.34":
-		return "gke-1.9.0"
-	default:
-		return "gke-1.9.0"
-	}
-}
-
-func ocpBenchmark(version string) string {
-	switch version {
-	case "3.10":
-		return "rh-0.7"
-	case "4.1":
-		return "rh-1.0"
-	case "4.11":
-		return "rh-1.4"
-	case "4.13":
-		return "rh-1.8"
-	default:
-		return ""
-	}
-}
-
-func k3sBenchmark(version string) string {
-	switch version {
-	case "1.22", "1.23":
-		return "k3s-cis-1.23"
-	case "1.24":
-		return "k3s-cis-1.24"
-	case "1.25":
-		return "k3s-cis-1.7"
-	case "1.26":
-		return "k3s-cis-1.8"
-	case "1.27", "1.28", "1.29":
-		return "k3s-cis-1.9"
-	default:
-		return "k3s-cis-1.9"
-	}
-}
-
-func rkeBenchmark(version string) string {
-	switch version {
-	case "1.23":
-		return "rke-cis-1.23"
-	case "1.24":
-		return "rke-cis-1.24"
-	case "1.25", "1.26", "1.27":
-		return "rke-cis-1.7"
-	default:
-		return "rke-cis-1.7"
-	}
-}
-
-func rke2Benchmark(version string) string {
-	switch version {
-	case "1.23":
-		return "rke2-cis-1.23"
-	case "1.24":
-		return "rke2-cis-1.24"
-	case "1.25":
-		return "rke2-cis-1.7"
-	case "1.26", "1.27":
-		return "rke2-cis-1.8"
-	default:
-		return "rke2-cis-1.8"
-	}
-}
-
-func getOpenShiftInfo() Platform {
-	glog.V(1).Info("Checking for oc")
-	_, err := exec.LookPath("oc")
-
-	if err == nil {
-		cmd := exec.Command("oc", "version")
-		out, err := cmd.CombinedOutput()
-
-		if err == nil {
-			versionRe := regexp.MustCompile(`oc v(\d+\.\d+)`)
-			subs := versionRe.FindStringSubmatch(string(out))
-			if len(subs) < 1 {
-				versionRe = regexp.MustCompile(`Client Version:\s*(\d+\.\d+)`)
-				subs = versionRe.FindStringSubmatch(string(out))
-			}
-			if len(subs) > 1 {
-				glog.V(2).Infof("OCP output '%s' \nplatform is %s \nocp %v", string(out), getPlatformInfoFromVersion(string(out)), subs[1])
-				ocpBenchmarkVersion, err := getOcpValidVersion(subs[1])
-				if err == nil {
-					return Platform{Name: "ocp", Version: ocpBenchmarkVersion}
-				} else {
-					glog.V(1).Infof("Can't get getOcpValidVersion: %v", err)
-				}
-			} else {
-				glog.V(1).Infof("Can't parse version output: %v", subs)
-			}
-		} else {
-			glog.V(1).Infof("Can't use oc command: %v", err)
-		}
-	} else {
-		glog.V(1).Infof("Can't find oc command: %v", err)
-	}
-	return Platform{}
-}
-
-func getOcpValidVersion(ocpVer string) (string, error) {
-	ocpOriginal := ocpVer
-	valid := []string{"3.10", "4.1", "4.11", "4.13"}
-	for !isEmpty(ocpVer) {
-		glog.V(3).Info(fmt.Sprintf("getOcpBenchmarkVersion check for ocp: %q \n", ocpVer))
-		if slices.Contains(valid, ocpVer) {
-			glog.V(1).Info(fmt.Sprintf("getOcpBenchmarkVersion found valid version for ocp: %q \n", ocpVer))
-			return ocpVer, nil
-		}
-		ocpVer = decrementVersion(ocpVer)
-	}
-
-	glog.V(1).Info(fmt.Sprintf("getOcpBenchmarkVersion unable to find a match for: %q", ocpOriginal))
-	return "", fmt.Errorf("unable to find a matching Benchmark Version match for ocp version: %s", ocpOriginal)
-}
-
-// IsRKE Identifies if the cluster belongs to Rancher Distribution RKE
-func IsRKE(ctx context.Context, k8sClient kubernetes.Interface) (bool, error) {
-	// if there are windows nodes then this should not be counted as rke.linux
-	windowsNodes, err := k8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{
-		Limit:         1,
-		LabelSelector: "kubernetes.io/os=windows",
-	})
-	if err != nil {
-		return false, err
-	}
-	if len(windowsNodes.Items) != 0 {
-		return false, nil
-	}
-
-	// Any node created by RKE should have the annotation, so just grab 1
-	nodes, err := k8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
-	if err != nil {
-		return false, err
-	}
-
-	if len(nodes.Items) == 0 {
-		return false, nil
-	}
-
-	annos := nodes.Items[0].Annotations
-	if _, ok := annos["rke.cattle.io/external-ip"]; ok {
-		return true, nil
-	}
-	if _, ok := annos["rke.cattle.io/internal-ip"]; ok {
-		return true, nil
-	}
-	return false, nil
-}
@@ -63,6 +63,8 @@ func TestVerifyBin(t *testing.T) {
 		{proc: "cmd", psOut: "/usr/bin/cmd", exp: true},
 		{proc: "cmd", psOut: "kube-cmd", exp: false},
 		{proc: "cmd", psOut: "/usr/bin/kube-cmd", exp: false},
+		{proc: "", psOut: "cmd", exp: false},
+		{proc: " ", psOut: "cmd", exp: false},
 	}
 
 	psFunc = fakeps
@@ -184,6 +186,51 @@ func TestGetBinaries(t *testing.T) {
 	}
 }
 
+func TestGetPids(t *testing.T) {
+	oldPidsFunc := pidsFunc
+	defer func() { pidsFunc = oldPidsFunc }()
+	pidsFunc = func(bin string) []int {
+		switch bin {
+		case "kube-apiserver":
+			return []int{412}
+		case "kubelet":
+			return []int{77, 1024}
+		}
+		return nil
+	}
+
+	binmap := map[string]string{
+		"apiserver": "kube-apiserver",
+		"kubelet":   "kubelet",
+		"proxy":     "kube-proxy",
+	}
+	assert.Equal(t, map[string]string{"apiserver": "412", "kubelet": "77"}, getPids(binmap))
+}
+
+func TestPidsEmptyBin(t *testing.T) {
+	for _, bin := range []string{"", " ", "''"} {
+		assert.Equal(t, 0, len(pids(bin)))
+	}
+}
+
+func TestMatchesBin(t *testing.T) {
+	cases := []struct {
+		bin  string
+		line string
+		exp  bool
+	}{
+		{bin: "kubelet", line: "kubelet --config=/var/lib/kubelet/config.yaml", exp: true},
+		{bin: "kubelet", line: "/usr/bin/kubelet --config=/var/lib/kubelet/config.yaml", exp: true},
+		{bin: "kubelet", line: "/usr/bin/dockerd --kubelet", exp: false},
+		{bin: "hyperkube kubelet", line: "/hyperkube kubelet --v=2", exp: true},
+	}
+	for _, c := range cases {
+		t.Run(c.line, func(t *testing.T) {
+			assert.Equal(t, c.exp, matchesBin(c.bin, c.line))
+		})
+	}
+}
+
 func TestMultiWordReplace(t *testing.T) {
 	cases := []struct {
 		input   string
@@ -207,20 +254,26 @@ func TestMultiWordReplace(t *testing.T) {
 }
 
 func Test_getVersionFromKubectlOutput(t *testing.T) {
-	ver := getVersionFromKubectlOutput(`{
+	ver, err := getVersionFromKubectlOutput(`{
   "serverVersion": {
     "major": "1",
     "minor": "8",
     "gitVersion": "v1.8.0"
   }
 }`)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
 	if ver.BaseVersion() != "1.8" {
 		t.Fatalf("Expected 1.8 got %s", ver.BaseVersion())
 	}
 
-	ver = getVersionFromKubectlOutput("Something completely different")
-	if ver.BaseVersion() != defaultKubeVersion {
-		t.Fatalf("Expected %s got %s", defaultKubeVersion, ver.BaseVersion())
+	if _, err = getVersionFromKubectlOutput("Something completely different"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	if _, err = getVersionFromKubectlOutput(`{"clientVersion": {"major": "1", "minor": "29"}}`); err == nil {
+		t.Fatalf("Expected an error without server version")
 	}
 }
 
@@ -578,308 +631,3 @@ func TestGetYamlFilesFromDir(t *testing.T) {
 	}
 }
 
-func Test_getPlatformNameFromKubectlOutput(t *testing.T) {
-	type args struct {
-		s string
-	}
-	tests := []struct {
-		name string
-		args args
-		want Platform
-	}{
-		{
-			name: "eks",
-			args: args{s: "v1.17.9-eks-4c6976"},
-			want: Platform{Name: "eks", Version: "1.17"},
-		},
-		{
-			name: "gke",
-			args: args{s: "v1.17.6-gke.1"},
-			want: Platform{Name: "gke", Version: "1.17"},
-		},
-		{
-			name: "ack",
-			args: args{s: "v1.18.8-aliyun.1"},
-			want: Platform{Name: "aliyun", Version: "1.18"},
-		},
-		{
-			name: "unknown",
-			args: args{s: "v1.17.6"},
-			want: Platform{},
-		},
-		{
-			name: "empty string",
-			args: args{s: ""},
-			want: Platform{},
-		},
-		{
-			name: "k3s",
-			args: args{s: "v1.27.6+k3s1"},
-			want: Platform{Name: "k3s", Version: "1.27"},
-		},
-		{
-			name: "rancher1",
-			args: args{s: "v1.25.13-rancher1-1"},
-			want: Platform{Name: "rancher1", Version: "1.25"},
-		},
-		{
-			name: "rke2",
-			args: args{s: "v1.27.6+rke2r1"},
-			want: Platform{Name: "rke2r", Version: "1.27"},
-		},
-		{
-			name: "aks",
-			args: args{s: "v1.27.6+aks1"},
-			want: Platform{Name: "aks", Version: "1.27"},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := getPlatformInfoFromVersion(tt.args.s)
-			assert.Equal(t, tt.want, got)
-		})
-	}
-}
-
-func Test_getPlatformBenchmarkVersion(t *testing.T) {
-	type args struct {
-		platform Platform
-	}
-	tests := []struct {
-		name string
-		args args
-		want string
-	}{
-		{
-			name: "eks 1.31",
-			args: args{
-				platform: Platform{Name: "eks", Version: "1.31"},
-			},
-			want: "eks-1.7.0",
-		},
-		{
-			name: "eks 1.29",
-			args: args{
-				platform: Platform{Name: "eks", Version: "1.29"},
-			},
-			want: "eks-1.7.0",
-		},
-		{
-			name: "eks 1.30",
-			args: args{
-				platform: Platform{Name: "eks", Version: "1.30"},
-			},
-			want: "eks-1.7.0",
-		},
-		{
-			name: "eks 1.32",
-			args: args{
-				platform: Platform{Name: "eks", Version: "1.32"},
-			},
-			want: "eks-1.8.0",
-		},
-		{
-			name: "eks 1.24",
-			args: args{
-				platform: Platform{Name: "eks", Version: "1.24"},
-			},
-			want: "eks-1.5.0",
-		},
-		{
-			name: "gke 1.19",
-			args: args{
-				platform: Platform{Name: "gke", Version: "1.19"},
-			},
-			want: "gke-1.0",
-		},
-		{
-			name: "gke 1.20",
-			args: args{
-				platform: Platform{Name: "gke", Version: "1.20"},
-			},
-			want: "gke-1.2.0",
-		},
-		{
-			name: "gke 1.22",
-			args: args{
-				platform: Platform{Name: "gke", Version: "1.22"},
-			},
-			want: "gke-1.2.0",
-		},
-		{
-			name: "gke 1.28",
-			args: args{
-				platform: Platform{Name: "gke", Version: "1.28"},
-			},
-			want: "gke-1.6.0",
-		},
-		{
-			name: "gke 1.30",
-			args: args{
-				platform: Platform{Name: "gke", Version: "1.30"},
-			},
-			want: "gke-1.8.0",
-		},
-		{
-			name: "gke 1.33",
-			args: args{
-				platform: Platform{Name: "gke", Version: "1.33"},
-			},
-			want: "gke-1.9.0",
-		},
-		{
-			name: "aliyun",
-			args: args{
-				platform: Platform{Name: "aliyun"},
-			},
-			want: "ack-1.0",
-		},
-		{
-			name: "unknown",
-			args: args{
-				platform: Platform{Name: "rh"},
-			},
-			want: "",
-		},
-		{
-			name: "empty",
-			args: args{
-				platform: Platform{},
-			},
-			want: "",
-		},
-		{
-			name: "openshift3",
-			args: args{
-				platform: Platform{Name: "ocp", Version: "3.10"},
-			},
-			want: "rh-0.7",
-		},
-		{
-			name: "openshift4",
-			args: args{
-				platform: Platform{Name: "ocp", Version: "4.11"},
-			},
-			want: "rh-1.4",
-		},
-		{
-			name: "openshift4",
-			args: args{
-				platform: Platform{Name: "ocp", Version: "4.13"},
-			},
-			want: "rh-1.8",
-		},
-		{
-			name: "openshift4",
-			args: args{
-				platform: Platform{Name: "ocp", Version: "4.1"},
-			},
-			want: "rh-1.0",
-		},
-		{
-			name: "k3s 1.25",
-			args: args{
-				platform: Platform{Name: "k3s", Version: "1.25"},
-			},
-			want: "k3s-cis-1.7",
-		},
-		{
-			name: "k3s 1.26",
-			args: args{
-				platform: Platform{Name: "k3s", Version: "1.26"},
-			},
-			want: "k3s-cis-1.8",
-		},
-		{
-			name: "k3s 1.27",
-			args: args{
-				platform: Platform{Name: "k3s", Version: "1.27"},
-			},
-			want: "k3s-cis-1.9",
-		},
-		{
-			name: "k3s 1.28",
-			args: args{
-				platform: Platform{Name: "k3s", Version: "1.28"},
-			},
-			want: "k3s-cis-1.9",
-		},
-		{
-			name: "k3s 1.29",
-			args: args{
-				platform: Platform{Name: "k3s", Version: "1.29"},
-			},
-			want: "k3s-cis-1.9",
-		},
-		{
-			name: "rancher1",
-			args: args{
-				platform: Platform{Name: "rancher", Version: "1.27"},
-			},
-			want: "rke-cis-1.7",
-		},
-		{
-			name: "rke2",
-			args: args{
-				platform: Platform{Name: "rke2r", Version: "1.25"},
-			},
-			want: "rke2-cis-1.7",
-		},
-		{
-			name: "rke2",
-			args: args{
-				platform: Platform{Name: "rke2r", Version: "1.26"},
-			},
-			want: "rke2-cis-1.8",
-		},
-		{
-			name: "aks",
-			args: args{
-				platform: Platform{Name: "aks", Version: "1.29"},
-			},
-			want: "aks-1.7",
-		},
-		{
-			name: "aks",
-			args: args{
-				platform: Platform{Name: "aks", Version: "1.32"},
-			},
-			want: "aks-1.8",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := getPlatformBenchmarkVersion(tt.args.platform); got != tt.want {
-				t.Errorf("getPlatformBenchmarkVersion() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_getOcpValidVersion(t *testing.T) {
-	cases := []struct {
-		openShiftVersion string
-		succeed          bool
-		exp              string
-	}{
-		{openShiftVersion: "3.11", succeed: true, exp: "3.10"},
-		{openShiftVersion: "3.10", succeed: true, exp: "3.10"},
-		{openShiftVersion: "2.9", succeed: false, exp: ""},
-		{openShiftVersion: "4.1", succeed: true, exp: "4.1"},
-		{openShiftVersion: "4.5", succeed: true, exp: "4.1"},
-		{openShiftVersion: "4.6", succeed: true, exp: "4.1"},
-		{openShiftVersion: "invalid", succeed: false, exp: ""},
-	}
-	for _, c := range cases {
-		ocpVer, _ := getOcpValidVersion(c.openShiftVersion)
-		if c.succeed {
-			if c.exp != ocpVer {
-				t.Errorf("getOcpValidVersion(%q) - Got %q expected %s", c.openShiftVersion, ocpVer, c.exp)
-			}
-		} else {
-			if len(ocpVer) > 0 {
-				t.Errorf("getOcpValidVersion(%q) - Expected empty string but Got %s", c.openShiftVersion, ocpVer)
-			}
-		}
-	}
-}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultVersionSource is reported as the source of the Kubernetes version when no
+// version source found it and defaultKubeVersion is assumed.
+const defaultVersionSource = "default"
+
+const missingVersionMessage = `
+Unable to detect the version of Kubernetes, assuming %s.
+kube-bench looks for it with the Kubernetes API, the node object, kubectl, the kube-apiserver
+static pod manifest, and the kubelet binary. To let it use kubectl or kubelet, make sure the
+/usr/local/mount-from-host/bin directory is mapped to the container,
+either in the job.yaml file, or Docker command.
+
+For job.yaml:
+...
+- name: usr-bin
+  mountPath: /usr/local/mount-from-host/bin
+...
+
+For docker command:
+   docker -v $(which kubectl):/usr/local/mount-from-host/bin/kubectl ....
+
+Alternatively, you can specify the version with --version
+   kube-bench --version <VERSION> ...
+
+Run with --strict-version to fail instead of assuming a version.
+`
+
+// VersionSource finds the version of Kubernetes kube-bench runs against.
+type VersionSource interface {
+	// Name identifies the source in logs and in the detected_version_source of reports.
+	Name() string
+	// Version returns the Kubernetes version, or an error if the source can't find it.
+	Version() (*KubeVersion, error)
+}
+
+// versionSourceFunc is a VersionSource calling a function.
+type versionSourceFunc struct {
+	name    string
+	version func() (*KubeVersion, error)
+}
+
+func (s versionSourceFunc) Name() string {
+	return s.name
+}
+
+func (s versionSourceFunc) Version() (*KubeVersion, error) {
+	return s.version()
+}
+
+// versionSources are tried in the order they are registered, until one finds the version.
+var versionSources []VersionSource
+
+func registerVersionSource(s VersionSource) {
+	versionSources = append(versionSources, s)
+}
+
+func init() {
+	registerVersionSource(versionSourceFunc{name: "rest-api", version: getKubeVersionFromRESTAPI})
+	registerVersionSource(versionSourceFunc{name: "node-info", version: getKubeVersionFromNodeInfo})
+	registerVersionSource(versionSourceFunc{name: "kubectl", version: getKubeVersionFromKubectl})
+	registerVersionSource(versionSourceFunc{name: "apiserver-image", version: getKubeVersionFromAPIServerImage})
+	registerVersionSource(versionSourceFunc{name: "kubelet-binary", version: getKubeVersionFromKubelet})
+}
+
+// getKubeVersion returns the Kubernetes version found by the first registered version source that finds it.
+func getKubeVersion() (*KubeVersion, error) {
+	return detectKubeVersion(versionSources, strictVersion)
+}
+
+// detectKubeVersion tries sources in order, and returns the version found by the first one that finds it,
+// with its Source set. When none does, it returns an error if strict is set, and defaultKubeVersion otherwise.
+func detectKubeVersion(sources []VersionSource, strict bool) (*KubeVersion, error) {
+	var errs []error
+	for _, s := range sources {
+		kv, err := s.Version()
+		if err != nil {
+			glog.V(2).Infof("Version source %s: %v", s.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %v", s.Name(), err))
+			continue
+		}
+		kv.Source = s.Name()
+		glog.V(2).Infof("Kubernetes version %s found by version source %s", kv.BaseVersion(), kv.Source)
+		return kv, nil
+	}
+
+	if strict {
+		return nil, fmt.Errorf("unable to detect the Kubernetes version:\n%v", errors.Join(errs...))
+	}
+	glog.Warningf(missingVersionMessage, defaultKubeVersion)
+	glog.V(1).Infof("Cant detect version, assuming default %s", defaultKubeVersion)
+	return &KubeVersion{baseVersion: defaultKubeVersion, Source: defaultVersionSource}, nil
+}
+
+var gitVersionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// kubeVersionFromGitVersion parses a Kubernetes git version such as v1.29.3 or v1.28.6+rke2r1.
+func kubeVersionFromGitVersion(gitVersion string) (*KubeVersion, error) {
+	subs := gitVersionRe.FindStringSubmatch(gitVersion)
+	if subs == nil {
+		return nil, fmt.Errorf("%q isn't a Kubernetes version", gitVersion)
+	}
+	return &KubeVersion{Major: subs[1], Minor: subs[2], GitVersion: gitVersion}, nil
+}
+
+// getKubeVersionFromNodeInfo reads the kubelet version the node object of this node reports.
+func getKubeVersionFromNodeInfo() (*KubeVersion, error) {
+	k8sClient, err := getInClusterClient()
+	if err != nil {
+		return nil, err
+	}
+	nodeName, err := getNodeName()
+	if err != nil {
+		return nil, err
+	}
+	return nodeInfoVersion(context.Background(), k8sClient, nodeName)
+}
+
+func nodeInfoVersion(ctx context.Context, k8sClient kubernetes.Interface, nodeName string) (*KubeVersion, error) {
+	node, err := k8sClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+	return kubeVersionFromGitVersion(node.Status.NodeInfo.KubeletVersion)
+}
+
+func getKubeVersionFromKubectl() (*KubeVersion, error) {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("kubectl", "version", "-o", "json")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		glog.V(2).Infof("Failed to query kubectl: %s", err)
+	}
+
+	return getVersionFromKubectlOutput(string(out))
+}
+
+func getVersionFromKubectlOutput(s string) (*KubeVersion, error) {
+	glog.V(2).Infof("Kubectl output: %s", s)
+	type versionResult struct {
+		ServerVersion *VersionResponse
+	}
+	vrObj := &versionResult{}
+	if err := json.Unmarshal([]byte(s), vrObj); err != nil {
+		if strings.Contains(s, "The connection to the server") {
+			return nil, fmt.Errorf("kubectl could not connect to the Kubernetes server. This may be because the kubeconfig information is missing or has credentials that do not match the server")
+		}
+		return nil, fmt.Errorf("unable to parse kubectl output: %v", err)
+	}
+	sv := vrObj.ServerVersion
+	if sv == nil {
+		return nil, fmt.Errorf("kubectl didn't report a server version")
+	}
+	return &KubeVersion{
+		Major:      sv.Major,
+		Minor:      sv.Minor,
+		GitVersion: sv.GitVersion,
+	}, nil
+}
+
+// apiserverManifests are the static pod manifests of kube-apiserver looked for by the apiserver-image source.
+var apiserverManifests = []string{
+	"/etc/kubernetes/manifests/kube-apiserver.yaml",
+	"/etc/kubernetes/manifests/kube-apiserver.manifest",
+	"/var/lib/rancher/rke2/agent/pod-manifests/kube-apiserver.yaml",
+}
+
+// staticPod is the part of a pod manifest read by the apiserver-image source.
+type staticPod struct {
+	Spec struct {
+		Containers []struct {
+			Name  string `yaml:"name"`
+			Image string `yaml:"image"`
+		} `yaml:"containers"`
+	} `yaml:"spec"`
+}
+
+// getKubeVersionFromAPIServerImage reads the version from the image tag of the kube-apiserver static pod.
+func getKubeVersionFromAPIServerImage() (*KubeVersion, error) {
+	for _, manifest := range apiserverManifests {
+		in, err := os.ReadFile(manifest)
+		if err != nil {
+			glog.V(3).Infof("Unable to read kube-apiserver manifest %s: %v", manifest, err)
+			continue
+		}
+
+		var pod staticPod
+		if err := yaml.Unmarshal(in, &pod); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", manifest, err)
+		}
+		for _, c := range pod.Spec.Containers {
+			if c.Name != "kube-apiserver" && !strings.Contains(c.Image, "kube-apiserver") {
+				continue
+			}
+			kv, err := kubeVersionFromGitVersion(imageTag(c.Image))
+			if err != nil {
+				return nil, fmt.Errorf("image %s of %s: %v", c.Image, manifest, err)
+			}
+			return kv, nil
+		}
+		return nil, fmt.Errorf("no kube-apiserver container in %s", manifest)
+	}
+	return nil, fmt.Errorf("no kube-apiserver static pod manifest found")
+}
+
+// imageTag returns the tag of a container image reference, or an empty string if it has none.
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	return image[i+1:]
+}
+
+// kubeletPaths are the usual locations of the kubelet binary, looked at when no kubelet process is found.
+var kubeletPaths = []string{
+	"/usr/bin/kubelet",
+	"/usr/local/bin/kubelet",
+	"/usr/local/mount-from-host/bin/kubelet",
+}
+
+// getKubeVersionFromKubelet reads the version embedded in the kubelet binary, of the running kubelet
+// if there is one. If the binary has no build information, it runs it with --version.
+func getKubeVersionFromKubelet() (*KubeVersion, error) {
+	var paths []string
+	for _, pid := range pidsFunc("kubelet") {
+		paths = append(paths, "/proc/"+strconv.Itoa(pid)+"/exe")
+	}
+	if path, err := exec.LookPath("kubelet"); err == nil {
+		paths = append(paths, path)
+	}
+	paths = append(paths, kubeletPaths...)
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		kv, err := kubeletEmbeddedVersion(path)
+		if err == nil {
+			return kv, nil
+		}
+		glog.V(2).Infof("Unable to read the version embedded in %s: %v", path, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), kubeletVersionTimeout)
+		out, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+		cancel()
+		if err != nil {
+			glog.V(2).Infof("Failed to query kubelet %s: %v", path, err)
+			continue
+		}
+		return getVersionFromKubeletOutput(string(out))
+	}
+	return nil, fmt.Errorf("no kubelet binary found")
+}
+
+var ldflagsGitVersionRe = regexp.MustCompile(`k8s\.io/component-base/version\.gitVersion=([^'"\s]+)`)
+
+// embeddedGitVersionRe matches a Kubernetes git version in the data of a binary. The linker stores
+// the value of a string set with -ldflags -X on its own, padded with NUL bytes.
+var embeddedGitVersionRe = regexp.MustCompile(`\x00(v1\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]*)?)\x00`)
+
+// embeddedVersionChunkSize is the size of the chunks a binary is scanned in for its git version.
+const embeddedVersionChunkSize = 1 << 20
+
+// kubeletVersionTimeout bounds kubelet --version, so that a binary that doesn't exit can't hang the scan.
+const kubeletVersionTimeout = 10 * time.Second
+
+// kubeletEmbeddedVersion reads the git version Kubernetes binaries are linked with, which sets
+// k8s.io/component-base/version.gitVersion, from the Go binary at path. Builds made with -trimpath,
+// such as the Kubernetes releases, leave -ldflags out of the build information, so the data of the
+// binary is scanned for the version instead.
+func kubeletEmbeddedVersion(path string) (*KubeVersion, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "-ldflags" {
+			if kv, err := versionFromLdflags(setting.Value); err == nil {
+				return kv, nil
+			}
+		}
+	}
+	return scanGitVersion(path)
+}
+
+// scanGitVersion looks for the git version stored in the binary at path, which must be the only one found.
+func scanGitVersion(path string) (*KubeVersion, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var versions []string
+	// Each chunk starts with the end of the previous one, for a version split across them
+	overlap := 64
+	buf := make([]byte, overlap+embeddedVersionChunkSize)
+	n := 0
+	for {
+		m, err := io.ReadFull(f, buf[n:])
+		data := buf[:n+m]
+		for _, subs := range embeddedGitVersionRe.FindAllSubmatch(data, -1) {
+			versions = appendUnique(versions, string(subs[1]))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		n = copy(buf, data[len(data)-overlap:])
+	}
+
+	switch len(versions) {
+	case 0:
+		return nil, fmt.Errorf("no git version found in the binary")
+	case 1:
+		return kubeVersionFromGitVersion(versions[0])
+	default:
+		return nil, fmt.Errorf("found several git versions in the binary: %s", strings.Join(versions, ", "))
+	}
+}
+
+func versionFromLdflags(ldflags string) (*KubeVersion, error) {
+	subs := ldflagsGitVersionRe.FindStringSubmatch(ldflags)
+	if subs == nil {
+		return nil, fmt.Errorf("no git version in -ldflags")
+	}
+	return kubeVersionFromGitVersion(subs[1])
+}
+
+var kubeletVersionRe = regexp.MustCompile(`Kubernetes (v\d+\.\d+\S*)`)
+
+func getVersionFromKubeletOutput(s string) (*KubeVersion, error) {
+	glog.V(2).Infof("Kubelet output: %s", s)
+	subs := kubeletVersionRe.FindStringSubmatch(s)
+	if len(subs) < 2 {
+		return nil, fmt.Errorf("unable to find the version in the kubelet output")
+	}
+	return kubeVersionFromGitVersion(subs[1])
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func staticVersionSource(name, gitVersion string) VersionSource {
+	return versionSourceFunc{name: name, version: func() (*KubeVersion, error) {
+		if gitVersion == "" {
+			return nil, errors.New("not found")
+		}
+		return kubeVersionFromGitVersion(gitVersion)
+	}}
+}
+
+func TestDetectKubeVersion(t *testing.T) {
+	testCases := []struct {
+		name      string
+		sources   []VersionSource
+		strict    bool
+		expBase   string
+		expSource string
+		expErr    string
+	}{
+		{
+			name:      "first source finding the version",
+			sources:   []VersionSource{staticVersionSource("rest-api", ""), staticVersionSource("node-info", "v1.29.3"), staticVersionSource("kubectl", "v1.30.1")},
+			expBase:   "1.29",
+			expSource: "node-info",
+		},
+		{
+			name:      "no source finds the version",
+			sources:   []VersionSource{staticVersionSource("rest-api", ""), staticVersionSource("kubectl", "")},
+			expBase:   defaultKubeVersion,
+			expSource: defaultVersionSource,
+		},
+		{
+			name:      "strict, with a source finding the version",
+			sources:   []VersionSource{staticVersionSource("apiserver-image", "v1.28.6+rke2r1")},
+			strict:    true,
+			expBase:   "1.28",
+			expSource: "apiserver-image",
+		},
+		{
+			name:    "strict, no source finds the version",
+			sources: []VersionSource{staticVersionSource("rest-api", ""), staticVersionSource("kubectl", "")},
+			strict:  true,
+			expErr:  "unable to detect the Kubernetes version:\nrest-api: not found\nkubectl: not found",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			kv, err := detectKubeVersion(c.sources, c.strict)
+			if c.expErr != "" {
+				assert.EqualError(t, err, c.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expBase, kv.BaseVersion())
+			assert.Equal(t, c.expSource, kv.Source)
+		})
+	}
+}
+
+func TestKubeVersionFromGitVersion(t *testing.T) {
+	testCases := []struct {
+		gitVersion string
+		expBase    string
+	}{
+		{gitVersion: "v1.29.3", expBase: "1.29"},
+		{gitVersion: "v1.27.8-eks-8cb36c9", expBase: "1.27"},
+		{gitVersion: "v1.28.6+rke2r1", expBase: "1.28"},
+		{gitVersion: "1.30.0", expBase: "1.30"},
+		{gitVersion: "latest"},
+		{gitVersion: ""},
+	}
+	for _, c := range testCases {
+		t.Run(c.gitVersion, func(t *testing.T) {
+			kv, err := kubeVersionFromGitVersion(c.gitVersion)
+			if c.expBase == "" {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expBase, kv.BaseVersion())
+			assert.Equal(t, c.gitVersion, kv.GitVersion)
+		})
+	}
+}
+
+func TestNodeInfoVersion(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.29.3"}},
+	})
+
+	kv, err := nodeInfoVersion(context.Background(), client, "node-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.29", kv.BaseVersion())
+
+	_, err = nodeInfoVersion(context.Background(), client, "node-2")
+	assert.Error(t, err)
+}
+
+func TestGetKubeVersionFromAPIServerImage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	kubeadm := write("kubeadm.yaml", `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-apiserver
+spec:
+  containers:
+  - name: kube-apiserver
+    image: registry.k8s.io/kube-apiserver:v1.29.3
+`)
+	digest := write("digest.yaml", `spec:
+  containers:
+  - name: apiserver
+    image: localhost:5000/kube-apiserver:v1.28.6@sha256:0123456789abcdef
+`)
+	noTag := write("notag.yaml", `spec:
+  containers:
+  - name: kube-apiserver
+    image: localhost:5000/kube-apiserver
+`)
+	other := write("other.yaml", `spec:
+  containers:
+  - name: etcd
+    image: registry.k8s.io/etcd:3.5.12-0
+`)
+
+	testCases := []struct {
+		name      string
+		manifests []string
+		expBase   string
+		expErr    string
+	}{
+		{name: "kubeadm", manifests: []string{filepath.Join(dir, "missing.yaml"), kubeadm}, expBase: "1.29"},
+		{name: "image with registry port and digest", manifests: []string{digest}, expBase: "1.28"},
+		{name: "image without tag", manifests: []string{noTag}, expErr: `isn't a Kubernetes version`},
+		{name: "no kube-apiserver container", manifests: []string{other}, expErr: "no kube-apiserver container"},
+		{name: "no manifest", manifests: []string{filepath.Join(dir, "missing.yaml")}, expErr: "no kube-apiserver static pod manifest found"},
+	}
+
+	defer func(manifests []string) { apiserverManifests = manifests }(apiserverManifests)
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			apiserverManifests = c.manifests
+			kv, err := getKubeVersionFromAPIServerImage()
+			if c.expErr != "" {
+				assert.ErrorContains(t, err, c.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expBase, kv.BaseVersion())
+		})
+	}
+}
+
+func TestVersionFromLdflags(t *testing.T) {
+	kv, err := versionFromLdflags(`-s -w -X 'k8s.io/component-base/version.buildDate=2024-03-15T00:00:00Z' -X 'k8s.io/component-base/version.gitVersion=v1.29.3' -X 'k8s.io/component-base/version.gitMajor=1'`)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.29", kv.BaseVersion())
+	assert.Equal(t, "v1.29.3", kv.GitVersion)
+
+	_, err = versionFromLdflags("-s -w")
+	assert.Error(t, err)
+
+	// The test binary isn't linked with a Kubernetes version.
+	exe, err := os.Executable()
+	assert.NoError(t, err)
+	_, err = kubeletEmbeddedVersion(exe)
+	assert.Error(t, err)
+}
+
+func TestKubeletEmbeddedVersionTrimpath(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go isn't installed")
+	}
+	dir := t.TempDir()
+	src := "package main\n\nvar gitVersion = \"v0.0.0-master+$Format:%H$\"\n\nfunc main() { println(gitVersion) }\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module kubelet\n"), 0o600))
+
+	// Kubernetes releases are built with -trimpath, which leaves -ldflags out of the build information
+	build := exec.Command(goBin, "build", "-trimpath", "-ldflags", "-s -w -X main.gitVersion=v1.31.2-eks-7f9249a", "-o", "kubelet", ".")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v: %s", err, out)
+	}
+
+	kv, err := kubeletEmbeddedVersion(filepath.Join(dir, "kubelet"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, kv) {
+		assert.Equal(t, "v1.31.2-eks-7f9249a", kv.GitVersion)
+		assert.Equal(t, "1.31", kv.BaseVersion())
+	}
+}
+
+func TestScanGitVersion(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 2*embeddedVersionChunkSize)
+	// Split across the first two chunks
+	copy(data[embeddedVersionChunkSize-4:], "\x00v1.29.3\x00")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "split"), data, 0o600))
+	copy(data[100:], "\x00v1.30.0+k3s1\x00")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "several"), data, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "none"), []byte("\x00v0.0.0-master\x00v1.29\x00"), 0o600))
+
+	kv, err := scanGitVersion(filepath.Join(dir, "split"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, kv) {
+		assert.Equal(t, "v1.29.3", kv.GitVersion)
+	}
+	_, err = scanGitVersion(filepath.Join(dir, "several"))
+	assert.EqualError(t, err, "found several git versions in the binary: v1.30.0+k3s1, v1.29.3")
+	_, err = scanGitVersion(filepath.Join(dir, "none"))
+	assert.EqualError(t, err, "no git version found in the binary")
+}
+
+func Test_getVersionFromKubeletOutput(t *testing.T) {
+	kv, err := getVersionFromKubeletOutput("Kubernetes v1.29.3\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.29", kv.BaseVersion())
+
+	_, err = getVersionFromKubeletOutput("kubelet: command not found")
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/aquasecurity/kube-bench/internal/webhook"
+	"github.com/golang/glog"
+	"github.com/spf13/viper"
+)
+
+// webhookEvent is the payload sent for a single failing check when
+// running with --webhook-events.
+type webhookEvent struct {
+	ControlsID      string         `json:"id"`
+	Version         string         `json:"version"`
+	DetectedVersion string         `json:"detected_version,omitempty"`
+	VersionSource   string         `json:"detected_version_source,omitempty"`
+	NodeType        check.NodeType `json:"node_type"`
+	Section         string         `json:"section"`
+	SectionDesc     string         `json:"section_desc"`
+	TestNumber      string         `json:"test_number"`
+	TestDesc        string         `json:"test_desc"`
+	Status          check.State    `json:"status"`
+	Scored          bool           `json:"scored"`
+	Remediation     string         `json:"remediation"`
+	ActualValue     string         `json:"actual_value"`
+	ExpectedResult  string         `json:"expected_result"`
+	Reason          string         `json:"reason,omitempty"`
+	Tags            []string       `json:"tags,omitempty"`
+	Time            string         `json:"time"`
+}
+
+func newWebhookSender() (*webhook.Sender, error) {
+	headers, err := parseWebhookHeaders(webhookHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook.New(webhook.Config{
+		URL:     webhookURL,
+		Headers: headers,
+		Secret:  viper.GetString("WEBHOOK_SECRET"),
+		Retries: webhookRetries,
+		Timeout: webhookTimeout,
+	}), nil
+}
+
+func parseWebhookHeaders(in []string) (map[string]string, error) {
+	headers := make(map[string]string, len(in))
+	for _, h := range in {
+		name, value, found := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid webhook header %q, expected \"Name: value\"", h)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+func writeWebhookOutput(controlsCollection []*check.Controls) {
+	sender, err := newWebhookSender()
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to set up webhook: %v", err))
+	}
+
+	if webhookEvents {
+		err = sendWebhookEvents(sender, getWebhookEvents(controlsCollection), webhookBatchSize)
+	} else {
+		err = sendWebhookReport(sender, controlsCollection)
+	}
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to output to webhook: %v", err))
+	}
+}
+
+func sendWebhookReport(sender *webhook.Sender, controlsCollection []*check.Controls) error {
+	var totals check.OverallControls
+	totals.Controls = controlsCollection
+	totals.Totals = getSummaryTotals(controlsCollection)
+	out, err := json.Marshal(totals)
+	if err != nil {
+		return err
+	}
+
+	if err := sender.Send(out); err != nil {
+		return err
+	}
+	glog.V(2).Info(fmt.Sprintf("successfully sent report to: %s", webhookURL))
+	return nil
+}
+
+// sendWebhookEvents sends events as JSON arrays of at most batchSize items.
+func sendWebhookEvents(sender *webhook.Sender, events []webhookEvent, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = len(events)
+	}
+
+	for i := 0; i < len(events); i += batchSize {
+		end := min(i+batchSize, len(events))
+		out, err := json.Marshal(events[i:end])
+		if err != nil {
+			return err
+		}
+		if err := sender.Send(out); err != nil {
+			return err
+		}
+	}
+	glog.V(2).Info(fmt.Sprintf("successfully sent %d events to: %s", len(events), webhookURL))
+	return nil
+}
+
+func getWebhookEvents(controlsCollection []*check.Controls) []webhookEvent {
+	var events []webhookEvent
+	ts := time.Now().Format(time.RFC3339)
+	for _, controls := range controlsCollection {
+		for _, g := range controls.Groups {
+			for _, c := range g.Checks {
+				if c.State != check.FAIL {
+					continue
+				}
+				events = append(events, webhookEvent{
+					ControlsID:      controls.ID,
+					Version:         controls.Version,
+					DetectedVersion: controls.DetectedVersion,
+					VersionSource:   controls.VersionSource,
+					NodeType:        controls.Type,
+					Section:         g.ID,
+					SectionDesc:     g.Text,
+					TestNumber:      c.ID,
+					TestDesc:        c.Text,
+					Status:          c.State,
+					Scored:          c.Scored,
+					Remediation:     c.Remediation,
+					ActualValue:     c.ActualValue,
+					ExpectedResult:  c.ExpectedResult,
+					Reason:          c.Reason,
+					Tags:            c.Tags,
+					Time:            ts,
+				})
+			}
+		}
+	}
+	return events
+}
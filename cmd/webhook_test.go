@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aquasecurity/kube-bench/check"
+	"github.com/aquasecurity/kube-bench/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWebhookHeaders(t *testing.T) {
+	headers, err := parseWebhookHeaders([]string{"Authorization: Bearer abc", "X-Empty:"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer abc", "X-Empty": ""}, headers)
+
+	_, err = parseWebhookHeaders([]string{"no-colon"})
+	assert.Error(t, err)
+
+	_, err = parseWebhookHeaders([]string{": value"})
+	assert.Error(t, err)
+}
+
+func TestGetWebhookEvents(t *testing.T) {
+	controlsCollection := []*check.Controls{
+		{
+			ID:   "1",
+			Type: check.MASTER,
+			Groups: []*check.Group{
+				{
+					ID: "1.1",
+					Checks: []*check.Check{
+						{ID: "1.1.1", State: check.PASS},
+						{ID: "1.1.2", State: check.FAIL, Remediation: "fix it", Tags: []string{"file-permissions"}},
+						{ID: "1.1.3", State: check.WARN},
+					},
+				},
+			},
+		},
+		{
+			ID:   "4",
+			Type: check.NODE,
+			Groups: []*check.Group{
+				{
+					ID:     "4.2",
+					Checks: []*check.Check{{ID: "4.2.1", State: check.FAIL}},
+				},
+			},
+		},
+	}
+
+	events := getWebhookEvents(controlsCollection)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "1.1.2", events[0].TestNumber)
+	assert.Equal(t, "1.1", events[0].Section)
+	assert.Equal(t, check.MASTER, events[0].NodeType)
+	assert.Equal(t, "fix it", events[0].Remediation)
+	assert.Equal(t, []string{"file-permissions"}, events[0].Tags)
+	assert.Equal(t, "4.2.1", events[1].TestNumber)
+	assert.Equal(t, check.FAIL, events[1].Status)
+}
+
+func TestSendWebhookEvents(t *testing.T) {
+	cases := []struct {
+		name      string
+		events    int
+		batchSize int
+		expSizes  []int
+	}{
+		{name: "single batch", events: 3, batchSize: 100, expSizes: []int{3}},
+		{name: "exact batches", events: 4, batchSize: 2, expSizes: []int{2, 2}},
+		{name: "partial last batch", events: 5, batchSize: 2, expSizes: []int{2, 2, 1}},
+		{name: "no batching", events: 5, batchSize: 0, expSizes: []int{5}},
+		{name: "no events", events: 0, batchSize: 2, expSizes: nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var sizes []int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var batch []webhookEvent
+				if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				sizes = append(sizes, len(batch))
+			}))
+			defer ts.Close()
+
+			events := make([]webhookEvent, c.events)
+			err := sendWebhookEvents(webhook.New(webhook.Config{URL: ts.URL}), events, c.batchSize)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expSizes, sizes)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package cri
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// DefaultEndpoints are the CRI sockets tried in order when no endpoint is given.
+var DefaultEndpoints = []string{
+	"unix:///run/containerd/containerd.sock",
+	"unix:///run/crio/crio.sock",
+	"unix:///run/k3s/containerd/containerd.sock",
+	"unix:///run/k0s/containerd.sock",
+}
+
+const (
+	podNameLabel      = "io.kubernetes.pod.name"
+	podNamespaceLabel = "io.kubernetes.pod.namespace"
+
+	// maxMsgSize allows for the verbose container status, which includes the whole OCI spec.
+	maxMsgSize = 16 * 1024 * 1024
+)
+
+// Container is a running container.
+type Container struct {
+	ID           string
+	Name         string
+	PodName      string
+	PodNamespace string
+	// Args is the command line of the container process, if the runtime reports it.
+	Args []string
+	// Env is the environment of the container process, as KEY=value entries.
+	Env    []string
+	Mounts []Mount
+}
+
+// Mount is a host path mounted into a container.
+type Mount struct {
+	ContainerPath string
+	HostPath      string
+}
+
+// CommandLine returns the arguments of the container process joined by spaces.
+func (c Container) CommandLine() string {
+	return strings.Join(c.Args, " ")
+}
+
+// HostPath returns the path on the host of the file at p inside the container,
+// or "" if p is not on a mount.
+func (c Container) HostPath(p string) string {
+	p = path.Clean(p)
+	best := -1
+	for i, m := range c.Mounts {
+		cp := path.Clean(m.ContainerPath)
+		if p != cp && !strings.HasPrefix(p, strings.TrimSuffix(cp, "/")+"/") {
+			continue
+		}
+		if best < 0 || len(cp) > len(path.Clean(c.Mounts[best].ContainerPath)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	m := c.Mounts[best]
+	return path.Join(m.HostPath, strings.TrimPrefix(p, path.Clean(m.ContainerPath)))
+}
+
+// Client queries a container runtime through its CRI socket.
+type Client struct {
+	endpoint string
+	conn     *grpc.ClientConn
+	runtime  runtimeapi.RuntimeServiceClient
+}
+
+// Dial connects to the CRI socket at endpoint, and checks that a runtime answers on it.
+// The endpoint is a unix:// URL or the path of the socket.
+func Dial(ctx context.Context, endpoint string) (*Client, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "unix://" + endpoint
+	}
+	conn, err := grpc.NewClient(endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", endpoint, err)
+	}
+
+	c := &Client{endpoint: endpoint, conn: conn, runtime: runtimeapi.NewRuntimeServiceClient(conn)}
+	if _, err := c.runtime.Version(ctx, &runtimeapi.VersionRequest{}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get runtime version from %s: %v", endpoint, err)
+	}
+	return c, nil
+}
+
+// Connect returns a Client for the first of endpoints that a runtime answers on.
+func Connect(ctx context.Context, endpoints []string) (*Client, error) {
+	var errs []string
+	for _, endpoint := range endpoints {
+		c, err := Dial(ctx, endpoint)
+		if err == nil {
+			return c, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, fmt.Errorf("no container runtime found: %s", strings.Join(errs, "; "))
+}
+
+// Endpoint returns the URL of the CRI socket the client is connected to.
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// Close closes the connection to the runtime.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Containers returns the running containers, ordered by pod namespace, pod name and name.
+func (c *Client) Containers(ctx context.Context) ([]Container, error) {
+	resp, err := c.runtime.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{
+			State: &runtimeapi.ContainerStateValue{State: runtimeapi.ContainerState_CONTAINER_RUNNING},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %v", err)
+	}
+
+	var containers []Container
+	for _, ctr := range resp.GetContainers() {
+		status, err := c.runtime.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: ctr.GetId(), Verbose: true})
+		if err != nil {
+			// The container may have exited since it was listed
+			continue
+		}
+
+		container := Container{
+			ID:           ctr.GetId(),
+			Name:         ctr.GetMetadata().GetName(),
+			PodName:      ctr.GetLabels()[podNameLabel],
+			PodNamespace: ctr.GetLabels()[podNamespaceLabel],
+		}
+		for _, m := range status.GetStatus().GetMounts() {
+			container.Mounts = append(container.Mounts, Mount{ContainerPath: m.GetContainerPath(), HostPath: m.GetHostPath()})
+		}
+		container.Args, container.Env = parseInfo(status.GetInfo())
+		containers = append(containers, container)
+	}
+
+	sort.Slice(containers, func(i, j int) bool {
+		a, b := containers[i], containers[j]
+		if a.PodNamespace != b.PodNamespace {
+			return a.PodNamespace < b.PodNamespace
+		}
+		if a.PodName != b.PodName {
+			return a.PodName < b.PodName
+		}
+		return a.Name < b.Name
+	})
+	return containers, nil
+}
+
+// parseInfo reads the process arguments and environment from the OCI runtime spec
+// that containerd and CRI-O include in the verbose container status.
+func parseInfo(info map[string]string) (args []string, env []string) {
+	var verbose struct {
+		RuntimeSpec struct {
+			Process struct {
+				Args []string `json:"args"`
+				Env  []string `json:"env"`
+			} `json:"process"`
+		} `json:"runtimeSpec"`
+	}
+	if err := json.Unmarshal([]byte(info["info"]), &verbose); err != nil {
+		return nil, nil
+	}
+	return verbose.RuntimeSpec.Process.Args, verbose.RuntimeSpec.Process.Env
+}
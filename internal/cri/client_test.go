@@ -0,0 +1,177 @@
+package cri
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakeRuntime is a CRI runtime serving a fixed set of containers.
+type fakeRuntime struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+	containers []*runtimeapi.Container
+	statuses   map[string]*runtimeapi.ContainerStatusResponse
+}
+
+func (f *fakeRuntime) Version(ctx context.Context, req *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	return &runtimeapi.VersionResponse{RuntimeName: "fake", RuntimeApiVersion: "v1"}, nil
+}
+
+func (f *fakeRuntime) ListContainers(ctx context.Context, req *runtimeapi.ListContainersRequest) (*runtimeapi.ListContainersResponse, error) {
+	var containers []*runtimeapi.Container
+	for _, c := range f.containers {
+		if req.GetFilter().GetState() == nil || c.GetState() == req.GetFilter().GetState().GetState() {
+			containers = append(containers, c)
+		}
+	}
+	return &runtimeapi.ListContainersResponse{Containers: containers}, nil
+}
+
+func (f *fakeRuntime) ContainerStatus(ctx context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	if s, ok := f.statuses[req.GetContainerId()]; ok {
+		return s, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// serveFake serves runtime on a unix socket and returns the socket path.
+func serveFake(t *testing.T, runtime *fakeRuntime) string {
+	// t.TempDir paths can be longer than a unix socket path allows
+	dir, err := os.MkdirTemp("", "cri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "cri.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(s, runtime)
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+	return socket
+}
+
+func newFakeRuntime() *fakeRuntime {
+	running := runtimeapi.ContainerState_CONTAINER_RUNNING
+	return &fakeRuntime{
+		containers: []*runtimeapi.Container{
+			{
+				Id:       "etcd1",
+				Metadata: &runtimeapi.ContainerMetadata{Name: "etcd"},
+				Labels:   map[string]string{podNameLabel: "etcd-kind-control-plane", podNamespaceLabel: "kube-system"},
+				State:    running,
+			},
+			{
+				Id:       "api1",
+				Metadata: &runtimeapi.ContainerMetadata{Name: "kube-apiserver"},
+				Labels:   map[string]string{podNameLabel: "kube-apiserver-kind-control-plane", podNamespaceLabel: "kube-system"},
+				State:    running,
+			},
+			{
+				Id:       "old1",
+				Metadata: &runtimeapi.ContainerMetadata{Name: "kube-apiserver"},
+				State:    runtimeapi.ContainerState_CONTAINER_EXITED,
+			},
+		},
+		statuses: map[string]*runtimeapi.ContainerStatusResponse{
+			"api1": {
+				Status: &runtimeapi.ContainerStatus{
+					Id: "api1",
+					Mounts: []*runtimeapi.Mount{
+						{ContainerPath: "/etc/kubernetes/pki", HostPath: "/etc/kubernetes/pki"},
+						{ContainerPath: "/etc/kubernetes/config", HostPath: "/var/lib/rancher/config"},
+					},
+				},
+				Info: map[string]string{"info": `{"pid":4242,"runtimeSpec":{"process":{"args":["kube-apiserver","--profiling=false"],"env":["PATH=/bin","GODEBUG=x509sha1=0"]}}}`},
+			},
+			"etcd1": {
+				Status: &runtimeapi.ContainerStatus{Id: "etcd1"},
+				Info:   map[string]string{"info": "not json"},
+			},
+		},
+	}
+}
+
+func TestContainers(t *testing.T) {
+	socket := serveFake(t, newFakeRuntime())
+
+	c, err := Dial(context.Background(), socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c.Close()
+	assert.Equal(t, "unix://"+socket, c.Endpoint())
+
+	containers, err := c.Containers(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Container{
+		{
+			ID:           "etcd1",
+			Name:         "etcd",
+			PodName:      "etcd-kind-control-plane",
+			PodNamespace: "kube-system",
+		},
+		{
+			ID:           "api1",
+			Name:         "kube-apiserver",
+			PodName:      "kube-apiserver-kind-control-plane",
+			PodNamespace: "kube-system",
+			Args:         []string{"kube-apiserver", "--profiling=false"},
+			Env:          []string{"PATH=/bin", "GODEBUG=x509sha1=0"},
+			Mounts: []Mount{
+				{ContainerPath: "/etc/kubernetes/pki", HostPath: "/etc/kubernetes/pki"},
+				{ContainerPath: "/etc/kubernetes/config", HostPath: "/var/lib/rancher/config"},
+			},
+		},
+	}, containers)
+	assert.Equal(t, "kube-apiserver --profiling=false", containers[1].CommandLine())
+}
+
+func TestConnect(t *testing.T) {
+	socket := serveFake(t, newFakeRuntime())
+
+	c, err := Connect(context.Background(), []string{"unix:///nonexistent/cri.sock", socket})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c.Close()
+	assert.Equal(t, "unix://"+socket, c.Endpoint())
+
+	_, err = Connect(context.Background(), []string{"unix:///nonexistent/cri.sock"})
+	assert.ErrorContains(t, err, "no container runtime found")
+}
+
+func TestHostPath(t *testing.T) {
+	c := Container{Mounts: []Mount{
+		{ContainerPath: "/etc/kubernetes", HostPath: "/etc/kubernetes"},
+		{ContainerPath: "/etc/kubernetes/config/", HostPath: "/var/lib/rancher/config"},
+		{ContainerPath: "/var/lib/kubelet/config.yaml", HostPath: "/srv/kubelet.yaml"},
+	}}
+
+	cases := []struct {
+		path string
+		exp  string
+	}{
+		{path: "/etc/kubernetes/admin.conf", exp: "/etc/kubernetes/admin.conf"},
+		{path: "/etc/kubernetes/config/audit.yaml", exp: "/var/lib/rancher/config/audit.yaml"},
+		{path: "/etc/kubernetes/configs/audit.yaml", exp: "/etc/kubernetes/configs/audit.yaml"},
+		{path: "/var/lib/kubelet/config.yaml", exp: "/srv/kubelet.yaml"},
+		{path: "/var/lib/kubelet/kubeconfig", exp: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.exp, c.HostPath(tc.path))
+		})
+	}
+}
@@ -0,0 +1,5 @@
+/*
+Package cri finds running containers, with their arguments, environment and mounts,
+by querying the container runtime (containerd or CRI-O) through its CRI socket.
+*/
+package cri
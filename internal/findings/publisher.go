@@ -2,15 +2,40 @@ package findings
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/securityhub"
 	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/golang/glog"
 	"github.com/pkg/errors"
 )
 
+const (
+	// batchSize is the maximum number of findings accepted by BatchImportFindings and BatchUpdateFindings.
+	batchSize = 100
+	// filterSize is the maximum number of values accepted by a GetFindings filter attribute.
+	filterSize = 20
+	// maxRetries is the number of times a throttled call is retried.
+	maxRetries = 5
+)
+
+// retryBackoff is the wait before the first retry of a throttled call,
+// doubled on every further attempt.
+var retryBackoff = time.Second
+
+// Client is the subset of the AWS Security Hub API used by a Publisher.
+type Client interface {
+	BatchImportFindings(ctx context.Context, params *securityhub.BatchImportFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.BatchImportFindingsOutput, error)
+	BatchUpdateFindings(ctx context.Context, params *securityhub.BatchUpdateFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.BatchUpdateFindingsOutput, error)
+	GetFindings(ctx context.Context, params *securityhub.GetFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.GetFindingsOutput, error)
+}
+
 // A Publisher represents an object that publishes finds to AWS Security Hub.
 type Publisher struct {
-	client securityhub.Client // AWS Security Hub Service Client
+	client Client // AWS Security Hub Service Client
 }
 
 // A PublisherOutput represents an object that contains information about the service call.
@@ -30,7 +55,7 @@ type PublisherOutput struct {
 }
 
 // New creates a new Publisher.
-func New(client securityhub.Client) *Publisher {
+func New(client Client) *Publisher {
 	return &Publisher{
 		client: client,
 	}
@@ -39,29 +64,159 @@ func New(client securityhub.Client) *Publisher {
 // PublishFinding publishes findings to AWS Security Hub Service
 func (p *Publisher) PublishFinding(finding []types.AwsSecurityFinding) (*PublisherOutput, error) {
 	o := PublisherOutput{}
-	i := securityhub.BatchImportFindingsInput{}
-	i.Findings = finding
 	var errs error
 
 	// Split the slice into batches of 100 finding.
-	batch := 100
-
-	for i := 0; i < len(finding); i += batch {
+	for start := 0; start < len(finding); start += batchSize {
+		end := min(start+batchSize, len(finding))
 		i := securityhub.BatchImportFindingsInput{}
-		i.Findings = finding
-		r, err := p.client.BatchImportFindings(context.Background(), &i) // Process the batch.
+		i.Findings = finding[start:end]
+
+		var r *securityhub.BatchImportFindingsOutput
+		err := withRetry(func() (err error) {
+			r, err = p.client.BatchImportFindings(context.Background(), &i) // Process the batch.
+			return err
+		})
 		if err != nil {
 			errs = errors.Wrap(err, "finding publish failed")
 		}
 		if r != nil {
-			if *r.FailedCount != 0 {
-				o.FailedCount += *r.FailedCount
-			}
-			if *r.SuccessCount != 0 {
-				o.SuccessCount += *r.SuccessCount
-			}
+			o.FailedCount += aws.ToInt32(r.FailedCount)
+			o.SuccessCount += aws.ToInt32(r.SuccessCount)
 			o.FailedFindings = append(o.FailedFindings, r.FailedFindings...)
 		}
 	}
 	return &o, errs
 }
+
+// ArchiveResolved publishes the resolved findings whose Id matches an active
+// finding in AWS Security Hub, so that findings imported for checks that
+// failed in an earlier run are archived once they pass, then sets their
+// workflow status to RESOLVED. BatchImportFindings ignores the workflow status
+// sent by finding providers, so it's set with BatchUpdateFindings.
+// Resolved findings that were never imported as active are not sent.
+func (p *Publisher) ArchiveResolved(resolved []types.AwsSecurityFinding) (*PublisherOutput, error) {
+	ids := make([]string, 0, len(resolved))
+	for _, f := range resolved {
+		ids = append(ids, aws.ToString(f.Id))
+	}
+
+	active, err := p.activeFindingIDs(ids)
+	if err != nil {
+		return &PublisherOutput{}, errors.Wrap(err, "failed to get active findings")
+	}
+
+	var archive []types.AwsSecurityFinding
+	for _, f := range resolved {
+		if active[aws.ToString(f.Id)] {
+			archive = append(archive, f)
+		}
+	}
+	glog.V(2).Infof("Archiving %d resolved findings", len(archive))
+
+	o, err := p.PublishFinding(archive)
+	if err != nil {
+		return o, err
+	}
+	return o, p.resolveWorkflow(archive)
+}
+
+// resolveWorkflow sets the workflow status of findings to RESOLVED.
+func (p *Publisher) resolveWorkflow(findings []types.AwsSecurityFinding) error {
+	var unprocessed []string
+
+	for start := 0; start < len(findings); start += batchSize {
+		end := min(start+batchSize, len(findings))
+		i := securityhub.BatchUpdateFindingsInput{
+			Workflow: &types.WorkflowUpdate{Status: types.WorkflowStatusResolved},
+		}
+		for _, f := range findings[start:end] {
+			i.FindingIdentifiers = append(i.FindingIdentifiers, types.AwsSecurityFindingIdentifier{Id: f.Id, ProductArn: f.ProductArn})
+		}
+
+		var r *securityhub.BatchUpdateFindingsOutput
+		err := withRetry(func() (err error) {
+			r, err = p.client.BatchUpdateFindings(context.Background(), &i)
+			return err
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve findings")
+		}
+		for _, u := range r.UnprocessedFindings {
+			var id string
+			if u.FindingIdentifier != nil {
+				id = aws.ToString(u.FindingIdentifier.Id)
+			}
+			unprocessed = append(unprocessed, fmt.Sprintf("%s: %s", id, aws.ToString(u.ErrorMessage)))
+		}
+	}
+
+	if len(unprocessed) > 0 {
+		return errors.Errorf("failed to resolve %d findings: %s", len(unprocessed), strings.Join(unprocessed, "; "))
+	}
+	return nil
+}
+
+// activeFindingIDs returns which of the given finding IDs are active in AWS Security Hub.
+func (p *Publisher) activeFindingIDs(ids []string) (map[string]bool, error) {
+	active := make(map[string]bool)
+
+	for start := 0; start < len(ids); start += filterSize {
+		end := min(start+filterSize, len(ids))
+		filters := &types.AwsSecurityFindingFilters{
+			RecordState: []types.StringFilter{
+				{Comparison: types.StringFilterComparisonEquals, Value: aws.String(string(types.RecordStateActive))},
+			},
+		}
+		for _, id := range ids[start:end] {
+			filters.Id = append(filters.Id, types.StringFilter{Comparison: types.StringFilterComparisonEquals, Value: aws.String(id)})
+		}
+
+		var token *string
+		for {
+			var r *securityhub.GetFindingsOutput
+			err := withRetry(func() (err error) {
+				r, err = p.client.GetFindings(context.Background(), &securityhub.GetFindingsInput{
+					Filters:   filters,
+					NextToken: token,
+				})
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range r.Findings {
+				active[aws.ToString(f.Id)] = true
+			}
+			if aws.ToString(r.NextToken) == "" {
+				break
+			}
+			token = r.NextToken
+		}
+	}
+
+	return active, nil
+}
+
+// withRetry calls fn, retrying with exponential backoff while it is throttled.
+func withRetry(fn func() error) error {
+	wait := retryBackoff
+	err := fn()
+	for attempt := 1; attempt <= maxRetries && isThrottled(err); attempt++ {
+		glog.V(2).Infof("Request throttled, retrying in %s (attempt %d of %d)", wait, attempt, maxRetries)
+		time.Sleep(wait)
+		wait *= 2
+		err = fn()
+	}
+	return err
+}
+
+func isThrottled(err error) bool {
+	if err == nil {
+		return false
+	}
+	// Security Hub reports exceeded request rates as LimitExceededException.
+	var te *types.ThrottlingException
+	var le *types.LimitExceededException
+	return errors.As(err, &te) || errors.As(err, &le)
+}
@@ -0,0 +1,141 @@
+package findings
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClient struct {
+	batches     [][]types.AwsSecurityFinding
+	throttled   int
+	active      map[string]bool
+	queries     int
+	updates     []*securityhub.BatchUpdateFindingsInput
+	unprocessed map[string]bool
+}
+
+func (c *fakeClient) BatchImportFindings(ctx context.Context, params *securityhub.BatchImportFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.BatchImportFindingsOutput, error) {
+	if c.throttled > 0 {
+		c.throttled--
+		return nil, &types.ThrottlingException{}
+	}
+	c.batches = append(c.batches, params.Findings)
+	return &securityhub.BatchImportFindingsOutput{
+		FailedCount:  aws.Int32(0),
+		SuccessCount: aws.Int32(int32(len(params.Findings))),
+	}, nil
+}
+
+func (c *fakeClient) GetFindings(ctx context.Context, params *securityhub.GetFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.GetFindingsOutput, error) {
+	c.queries++
+	out := &securityhub.GetFindingsOutput{}
+	for _, f := range params.Filters.Id {
+		if c.active[aws.ToString(f.Value)] {
+			out.Findings = append(out.Findings, types.AwsSecurityFinding{Id: f.Value})
+		}
+	}
+	return out, nil
+}
+
+func (c *fakeClient) BatchUpdateFindings(ctx context.Context, params *securityhub.BatchUpdateFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.BatchUpdateFindingsOutput, error) {
+	c.updates = append(c.updates, params)
+	out := &securityhub.BatchUpdateFindingsOutput{}
+	for _, id := range params.FindingIdentifiers {
+		if c.unprocessed[aws.ToString(id.Id)] {
+			out.UnprocessedFindings = append(out.UnprocessedFindings, types.BatchUpdateFindingsUnprocessedFinding{
+				FindingIdentifier: &id,
+				ErrorCode:         aws.String("FindingNotFound"),
+				ErrorMessage:      aws.String("finding not found"),
+			})
+			continue
+		}
+		out.ProcessedFindings = append(out.ProcessedFindings, id)
+	}
+	return out, nil
+}
+
+func makeFindings(n int) []types.AwsSecurityFinding {
+	fs := make([]types.AwsSecurityFinding, n)
+	for i := range fs {
+		fs[i].Id = aws.String(fmt.Sprintf("finding-%d", i))
+	}
+	return fs
+}
+
+func TestPublishFinding(t *testing.T) {
+	retryBackoff = 0
+	cases := []struct {
+		name      string
+		findings  int
+		throttled int
+		expSizes  []int
+	}{
+		{name: "no findings", findings: 0},
+		{name: "single batch", findings: 42, expSizes: []int{42}},
+		{name: "full batches", findings: 200, expSizes: []int{100, 100}},
+		{name: "partial last batch", findings: 250, expSizes: []int{100, 100, 50}},
+		{name: "throttled", findings: 150, throttled: 2, expSizes: []int{100, 50}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &fakeClient{throttled: c.throttled}
+			out, err := New(client).PublishFinding(makeFindings(c.findings))
+			assert.NoError(t, err)
+			assert.Equal(t, int32(c.findings), out.SuccessCount)
+
+			var sizes []int
+			seen := make(map[string]bool)
+			for _, b := range client.batches {
+				sizes = append(sizes, len(b))
+				for _, f := range b {
+					assert.False(t, seen[*f.Id], "finding %s imported twice", *f.Id)
+					seen[*f.Id] = true
+				}
+			}
+			assert.Equal(t, c.expSizes, sizes)
+		})
+	}
+}
+
+func TestPublishFindingThrottledTooOften(t *testing.T) {
+	retryBackoff = 0
+	client := &fakeClient{throttled: maxRetries + 1}
+	_, err := New(client).PublishFinding(makeFindings(1))
+	assert.Error(t, err)
+}
+
+func TestArchiveResolved(t *testing.T) {
+	resolved := makeFindings(45)
+	client := &fakeClient{active: map[string]bool{"finding-3": true, "finding-44": true}}
+
+	out, err := New(client).ArchiveResolved(resolved)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), out.SuccessCount)
+	// 45 ids are looked up 20 at a time
+	assert.Equal(t, 3, client.queries)
+	assert.Len(t, client.batches, 1)
+	assert.Equal(t, "finding-3", *client.batches[0][0].Id)
+	assert.Equal(t, "finding-44", *client.batches[0][1].Id)
+
+	// The workflow status of the archived findings is set with BatchUpdateFindings
+	assert.Len(t, client.updates, 1)
+	assert.Equal(t, types.WorkflowStatusResolved, client.updates[0].Workflow.Status)
+	assert.Equal(t, []types.AwsSecurityFindingIdentifier{{Id: aws.String("finding-3")}, {Id: aws.String("finding-44")}}, client.updates[0].FindingIdentifiers)
+}
+
+func TestArchiveResolvedUnprocessed(t *testing.T) {
+	client := &fakeClient{
+		active:      map[string]bool{"finding-1": true, "finding-2": true},
+		unprocessed: map[string]bool{"finding-2": true},
+	}
+
+	_, err := New(client).ArchiveResolved(makeFindings(3))
+	assert.EqualError(t, err, "failed to resolve 1 findings: finding-2: finding not found")
+}
@@ -0,0 +1,4 @@
+/*
+Package procfs finds running processes by reading /proc, without the ps command.
+*/
+package procfs
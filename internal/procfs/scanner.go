@@ -0,0 +1,128 @@
+package procfs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultRoot is where the proc filesystem is normally mounted.
+const DefaultRoot = "/proc"
+
+// maxCommLen is the length the kernel truncates process names to in /proc/<pid>/comm.
+const maxCommLen = 15
+
+// Process is a running process.
+type Process struct {
+	PID int
+	// Comm is the process name, truncated by the kernel to 15 characters.
+	Comm string
+	// Exe is the path of the executable, if it can be read.
+	Exe string
+	// Args is the command line of the process.
+	Args []string
+}
+
+// CommandLine returns the arguments of the process joined by spaces.
+func (p Process) CommandLine() string {
+	return strings.Join(p.Args, " ")
+}
+
+// Scanner reads processes from a proc filesystem.
+type Scanner struct {
+	root string
+}
+
+// NewScanner returns a Scanner for the proc filesystem mounted at root,
+// or at DefaultRoot if root is empty.
+func NewScanner(root string) *Scanner {
+	if root == "" {
+		root = DefaultRoot
+	}
+	return &Scanner{root: root}
+}
+
+// Processes returns the running processes, ordered by PID. Kernel threads,
+// which have no command line, and processes that exit while they are read are skipped.
+func (s *Scanner) Processes() ([]Process, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []Process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		p, ok := s.read(pid)
+		if ok {
+			procs = append(procs, p)
+		}
+	}
+
+	sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+	return procs, nil
+}
+
+func (s *Scanner) read(pid int) (Process, bool) {
+	dir := filepath.Join(s.root, strconv.Itoa(pid))
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil || len(cmdline) == 0 {
+		return Process{}, false
+	}
+
+	p := Process{PID: pid, Args: splitCmdline(cmdline)}
+	if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		p.Comm = strings.TrimSpace(string(comm))
+	}
+	// Reading the executable of another user's process needs privileges
+	if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		p.Exe = exe
+	}
+	return p, true
+}
+
+func splitCmdline(cmdline []byte) []string {
+	cmdline = bytes.TrimRight(cmdline, "\x00")
+	var args []string
+	for _, arg := range bytes.Split(cmdline, []byte{0}) {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+// Find returns the running processes named name, as ps -C would: the process name,
+// the base name of its executable or the base name of its first argument is name.
+func (s *Scanner) Find(name string) ([]Process, error) {
+	procs, err := s.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Process
+	for _, p := range procs {
+		if p.matches(name) {
+			found = append(found, p)
+		}
+	}
+	return found, nil
+}
+
+func (p Process) matches(name string) bool {
+	comm := name
+	if len(comm) > maxCommLen {
+		comm = comm[:maxCommLen]
+	}
+	if p.Comm != "" && p.Comm == comm {
+		return true
+	}
+	if p.Exe != "" && filepath.Base(p.Exe) == name {
+		return true
+	}
+	return len(p.Args) > 0 && filepath.Base(p.Args[0]) == name
+}
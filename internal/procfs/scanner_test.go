@@ -0,0 +1,94 @@
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProcess struct {
+	pid     int
+	comm    string
+	exe     string
+	cmdline string
+}
+
+func fakeProc(t *testing.T, procs ...fakeProcess) string {
+	root := t.TempDir()
+	for _, p := range procs {
+		dir := filepath.Join(root, strconv.Itoa(p.pid))
+		assert.NoError(t, os.Mkdir(dir, 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "comm"), []byte(p.comm+"\n"), 0o644))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(p.cmdline), 0o644))
+		if p.exe != "" {
+			assert.NoError(t, os.Symlink(p.exe, filepath.Join(dir, "exe")))
+		}
+	}
+	// Entries that are not processes
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "sys"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "uptime"), []byte("1.0 1.0"), 0o644))
+	return root
+}
+
+func TestScannerProcesses(t *testing.T) {
+	root := fakeProc(t,
+		fakeProcess{pid: 200, comm: "kubelet", exe: "/usr/bin/kubelet", cmdline: "/usr/bin/kubelet\x00--config\x00/var/lib/kubelet/config.yaml\x00"},
+		fakeProcess{pid: 2, comm: "kthreadd"},
+		fakeProcess{pid: 31, comm: "containerd", cmdline: "/usr/bin/containerd\x00"},
+	)
+
+	procs, err := NewScanner(root).Processes()
+	assert.NoError(t, err)
+	if assert.Len(t, procs, 2) {
+		assert.Equal(t, 31, procs[0].PID)
+		assert.Equal(t, "", procs[0].Exe)
+		assert.Equal(t, 200, procs[1].PID)
+		assert.Equal(t, "kubelet", procs[1].Comm)
+		assert.Equal(t, "/usr/bin/kubelet", procs[1].Exe)
+		assert.Equal(t, []string{"/usr/bin/kubelet", "--config", "/var/lib/kubelet/config.yaml"}, procs[1].Args)
+		assert.Equal(t, "/usr/bin/kubelet --config /var/lib/kubelet/config.yaml", procs[1].CommandLine())
+	}
+}
+
+func TestScannerFind(t *testing.T) {
+	root := fakeProc(t,
+		fakeProcess{pid: 10, comm: "kube-controller", cmdline: "kube-controller-manager\x00--profiling=false\x00"},
+		fakeProcess{pid: 20, comm: "kubelet", cmdline: "/usr/bin/kubelet\x00--node-ip=10.0.0.1\x00"},
+		fakeProcess{pid: 30, comm: "kubelet", cmdline: "/opt/bin/kubelet\x00--node-ip=10.0.0.2\x00"},
+		fakeProcess{pid: 40, comm: "hyperkube", cmdline: "/hyperkube\x00kube-apiserver\x00"},
+		fakeProcess{pid: 50, comm: "runc:[2:INIT]", exe: "/usr/local/bin/etcd", cmdline: "etcd-wrapper\x00"},
+		fakeProcess{pid: 60, comm: "kube-apiserver-", cmdline: "kube-apiserver-proxy\x00"},
+	)
+	s := NewScanner(root)
+
+	cases := []struct {
+		name string
+		pids []int
+	}{
+		{name: "kubelet", pids: []int{20, 30}},
+		{name: "kube-controller-manager", pids: []int{10}},
+		{name: "hyperkube", pids: []int{40}},
+		{name: "etcd", pids: []int{50}},
+		{name: "kube-apiserver", pids: nil},
+		{name: "kube-scheduler", pids: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			procs, err := s.Find(c.name)
+			assert.NoError(t, err)
+			var pids []int
+			for _, p := range procs {
+				pids = append(pids, p.PID)
+			}
+			assert.Equal(t, c.pids, pids)
+		})
+	}
+}
+
+func TestScannerMissingRoot(t *testing.T) {
+	_, err := NewScanner(filepath.Join(t.TempDir(), "missing")).Find("kubelet")
+	assert.Error(t, err)
+}
@@ -0,0 +1,4 @@
+/*
+Package webhook handles sending results to a generic HTTP endpoint.
+*/
+package webhook
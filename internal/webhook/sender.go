@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of the request body.
+	SignatureHeader = "X-Kube-Bench-Signature"
+	// SignaturePrefix is prepended to the hex encoded signature.
+	SignaturePrefix = "sha256="
+
+	defaultBackoff = time.Second
+	defaultTimeout = 30 * time.Second
+)
+
+// Config holds the settings used to build a Sender.
+type Config struct {
+	// URL is the endpoint the payloads are POSTed to.
+	URL string
+	// Headers are extra HTTP headers added to every request.
+	Headers map[string]string
+	// Secret is the key used to sign request bodies. No signature is sent if empty.
+	Secret string
+	// Retries is the number of times a failed request is retried.
+	Retries int
+	// Backoff is the wait before the first retry, doubled on every further attempt.
+	Backoff time.Duration
+	// Timeout bounds every request, including reading the response, when Client is nil.
+	// Defaults to 30 seconds.
+	Timeout time.Duration
+	// Client is the HTTP client used to send requests. A client with Timeout is used if nil.
+	Client *http.Client
+}
+
+// A Sender POSTs JSON payloads to a webhook endpoint.
+type Sender struct {
+	cfg Config
+}
+
+// New creates a new Sender.
+func New(cfg Config) *Sender {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultBackoff
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	return &Sender{cfg: cfg}
+}
+
+// Send POSTs body to the endpoint, retrying with exponential backoff on
+// connection errors, 429 and 5xx responses.
+func (s *Sender) Send(body []byte) error {
+	var err error
+	wait := s.cfg.Backoff
+
+	for attempt := 0; attempt <= s.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			glog.V(2).Infof("Retrying webhook request in %s (attempt %d of %d)", wait, attempt, s.cfg.Retries)
+			time.Sleep(wait)
+			wait *= 2
+		}
+
+		var retry bool
+		retry, err = s.post(body)
+		if err == nil || !retry {
+			return err
+		}
+		glog.V(2).Info(err)
+	}
+
+	return err
+}
+
+func (s *Sender) post(body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(body, s.cfg.Secret))
+	}
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("URL:[%s], StatusCode:[%d]", s.cfg.URL, resp.StatusCode)
+	}
+
+	return false, nil
+}
+
+// Sign returns the value of the signature header for body, that is the
+// hex encoded HMAC-SHA256 of body keyed with secret.
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	cases := []struct {
+		name     string
+		statuses []int
+		retries  int
+		expCalls int
+		fail     bool
+	}{
+		{name: "ok", statuses: []int{http.StatusOK}, retries: 3, expCalls: 1},
+		{name: "retry on 500", statuses: []int{http.StatusInternalServerError, http.StatusAccepted}, retries: 3, expCalls: 2},
+		{name: "retry on 429", statuses: []int{http.StatusTooManyRequests, http.StatusOK}, retries: 1, expCalls: 2},
+		{name: "retries exhausted", statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, retries: 2, expCalls: 3, fail: true},
+		{name: "no retry on 400", statuses: []int{http.StatusBadRequest, http.StatusOK}, retries: 3, expCalls: 1, fail: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.statuses[calls])
+				calls++
+			}))
+			defer ts.Close()
+
+			s := New(Config{URL: ts.URL, Retries: c.retries, Backoff: time.Millisecond})
+			err := s.Send([]byte(`{}`))
+			if c.fail && err == nil {
+				t.Errorf("Expected error")
+			}
+			if !c.fail && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if calls != c.expCalls {
+				t.Errorf("Expected %d calls, got %d", c.expCalls, calls)
+			}
+		})
+	}
+}
+
+func TestSendTimeout(t *testing.T) {
+	done := make(chan struct{})
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	s := New(Config{URL: ts.URL, Retries: 1, Backoff: time.Millisecond, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	if err := s.Send([]byte(`{}`)); err == nil {
+		t.Errorf("Expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the requests to time out, took %s", elapsed)
+	}
+	if calls != 2 {
+		t.Errorf("Expected timed out request to be retried, got %d calls", calls)
+	}
+}
+
+func TestNewDefaultTimeout(t *testing.T) {
+	s := New(Config{URL: "http://localhost"})
+	if s.cfg.Client.Timeout != defaultTimeout {
+		t.Errorf("Expected client timeout %s, got %s", defaultTimeout, s.cfg.Client.Timeout)
+	}
+}
+
+func TestSendHeadersAndSignature(t *testing.T) {
+	body := []byte(`{"Totals":{"total_fail":1}}`)
+	secret := "s3cr3t"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		if string(got) != string(body) {
+			t.Errorf("Expected body %s, got %s", body, got)
+		}
+		if v := r.Header.Get("Content-Type"); v != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", v)
+		}
+		if v := r.Header.Get("X-Team"); v != "platform" {
+			t.Errorf("Expected X-Team header, got %q", v)
+		}
+		if v := r.Header.Get(SignatureHeader); v != Sign(got, secret) {
+			t.Errorf("Expected signature %q, got %q", Sign(got, secret), v)
+		}
+	}))
+	defer ts.Close()
+
+	s := New(Config{URL: ts.URL, Secret: secret, Headers: map[string]string{"X-Team": "platform"}})
+	if err := s.Send(body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n 'hello' | openssl dgst -sha256 -hmac 'key'
+	exp := "sha256=9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b"
+	if got := Sign([]byte("hello"), "key"); got != exp {
+		t.Errorf("Expected %s, got %s", exp, got)
+	}
+}